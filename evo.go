@@ -1,18 +1,675 @@
 package main
 
 import (
-	"github.com/erisod/evogo/evo"
+	"context"
+	"flag"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/erisod/evogo/evo"
+)
+
+// Exit codes.
+const (
+	EXITSOLVED   = 0 // Run found a solution; other commands succeeded.
+	EXITERROR    = 1 // Bad input or I/O failure.
+	EXITUSAGE    = 2 // Bad command line.
+	EXITUNSOLVED = 3 // Run ended without a solution.
 )
 
-// Global random number generator.
+const usage = `usage: evogo <command> [flags] [args]
+
+commands:
+  run            evolve a solution to a problem
+  list-problems  list the problems run accepts
+  disasm FILE    print a saved program with explanations
+  exec FILE      run a saved program against an input
+  worker         host islands for a run with --remote or --accept
+
+Run "evogo <command> -h" for the command's flags.
+`
 
 func main() {
-	var problem evo.Output1Problem
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(EXITUSAGE)
+	}
+
+	var code int
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "run":
+		code = runCmd(args)
+	case "list-problems":
+		code = listProblemsCmd(args)
+	case "disasm":
+		code = disasmCmd(args)
+	case "exec":
+		code = execCmd(args)
+	case "worker":
+		code = workerCmd(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "evogo: unknown command %q\n\n%s", cmd, usage)
+		code = EXITUSAGE
+	}
+
+	os.Exit(code)
+}
+
+// Register flags for the Config fields on fs.
+func configFlags(fs *flag.FlagSet, c *evo.Config) {
+	fs.IntVar(&c.Forms, "pop", c.Forms, "number of forms in the population")
+	fs.IntVar(&c.StabilityDuration, "stability", c.StabilityDuration, "iterations a solution's cost must hold to be stable")
+	fs.IntVar(&c.RaceTrials, "trials", c.RaceTrials, "inputs each form is scored on per iteration")
+	fs.IntVar(&c.CodeSize, "code-size", c.CodeSize, "instructions per form")
+	fs.IntVar(&c.MemSize, "mem-size", c.MemSize, "memory cells per form")
+	fs.IntVar(&c.IOSize, "io-size", c.IOSize, "output cells per form")
+	fs.IntVar(&c.MaxOps, "max-ops", c.MaxOps, "instructions executed per run of a form")
+	fs.IntVar(&c.MutationRate, "mutation-rate", c.MutationRate, "1 in N odds of each mutation")
+	fs.Int64Var(&c.Seed, "seed", c.Seed, "random seed (0 seeds from the clock)")
+	fs.Float64Var(&c.CrossoverRate, "crossover-rate", c.CrossoverRate, "chance (0 to 1) a child is bred by crossover")
+	fs.StringVar(&c.Crossover, "crossover", c.Crossover, "crossover operator: "+strings.Join(evo.CrossoverNames(), ", "))
+	fs.StringVar(&c.Selection, "selection", c.Selection, "parent selection: "+strings.Join(evo.SelectionNames(), ", "))
+	fs.IntVar(&c.TournamentSize, "tournament-size", c.TournamentSize, "forms per tournament for tournament selection")
+	fs.Float64Var(&c.RankPressure, "rank-pressure", c.RankPressure, "selective pressure (1 to 2) for rank selection")
+	fs.Float64Var(&c.TruncationPercent, "truncation-percent", c.TruncationPercent, "percentage of forms that breed under truncation selection")
+	fs.Float64Var(&c.Novelty, "novelty", c.Novelty, "weight (0 to 1) of novelty against score in selection (0 for none)")
+	fs.IntVar(&c.NoveltyK, "novelty-k", c.NoveltyK, "nearest neighbours novelty is measured against")
+	fs.IntVar(&c.NoveltyProbes, "novelty-probes", c.NoveltyProbes, "probe inputs whose outputs are a form's behavior")
+	fs.IntVar(&c.NoveltyArchive, "novelty-archive", c.NoveltyArchive, "past novel behaviors kept")
+	fs.IntVar(&c.Elites, "elites", c.Elites, "best forms copied unchanged into each generation")
+	fs.IntVar(&c.HallOfFame, "hall-of-fame", c.HallOfFame, "distinct best programs to keep and report (0 for none)")
+	fs.IntVar(&c.TrainingCases, "training-cases", c.TrainingCases, "score every form on this many fixed cases instead of fresh inputs (0 for fresh)")
+	fs.IntVar(&c.ValidationCases, "validation-cases", c.ValidationCases, "held-out cases a solution must also solve (0 for none)")
+	fs.Int64Var(&c.CaseSeed, "case-seed", c.CaseSeed, "seed for the training and validation cases (0 uses the run's seed)")
+	fs.IntVar(&c.Workers, "workers", c.Workers, "evaluation goroutines (0 for GOMAXPROCS)")
+}
+
+// Parse flags, allowing them before and after positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// What run drives: an Evolver, an Archipelago with --islands, a Coordinator
+// with --remote or --accept, or MapElites with --map-elites.
+type runner interface {
+	AddObserver(o evo.Observer)
+	StopWhen(conds ...evo.StopCondition)
+	Run(ctx context.Context) (evo.Result, error)
+}
+
+func runCmd(args []string) int {
+	c := evo.DefaultConfig()
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	problemName := fs.String("problem", "output1", "problem to solve (see list-problems), or a problem file ending in .json")
+	maxIterations := fs.Int("max-iterations", 0, "stop after N iterations (0 for no limit)")
+	timeout := fs.Duration("timeout", 0, "stop after this much wall-clock time (0 for no limit)")
+	maxEvals := fs.Int64("max-evals", 0, "stop after N form runs (0 for no limit)")
+	targetScore := fs.Float64("target-score", 0, "stop when the best score reaches this (scores are <= 0)")
+	useTarget := false
+	stagnation := fs.Int("stagnation", 0, "stop when the best score hasn't improved for N iterations (0 for never)")
+	checkpointDir := fs.String("checkpoint-dir", "", "directory to write checkpoints to")
+	checkpointEvery := fs.Int("checkpoint-every", 10, "iterations between checkpoints")
+	resume := fs.Bool("resume", false, "resume from the checkpoint in --checkpoint-dir")
+	out := fs.String("out", "", "file to save the best program to")
+	events := fs.String("events", "", "file to write JSON lines progress events to")
+	islands := fs.Int("islands", 1, "number of populations evolved in parallel with migration between them")
+	migrationInterval := fs.Int("migration-interval", 25, "iterations between migrations")
+	migrants := fs.Int("migrants", 5, "best forms each island sends per migration")
+	topology := fs.String("topology", evo.TOPOLOGYRING, "migration topology: "+strings.Join(evo.TopologyNames(), ", "))
+	remote := fs.String("remote", "", "comma separated addresses of evogo workers to run islands on")
+	accept := fs.String("accept", "", "address to accept joining evogo workers on")
+	islandSelections := fs.String("island-selections", "", "comma separated selection per island, repeated as needed (default --selection)")
+	mapElites := fs.Bool("map-elites", false, "fill a MAP-Elites grid of the best form per feature cell instead of evolving one population")
+	features := fs.String("features", "steps,opcodes", "comma separated grid axes for --map-elites as NAME or NAME:BINS; names: "+strings.Join(evo.FeatureNames(), ", "))
+	probes := fs.Int("probes", 10, "inputs features are measured on for --map-elites")
+	gridFile := fs.String("grid", "", "file to write the --map-elites grid to as JSON")
+	dataset := fs.String("dataset", "", "CSV file of examples to fit instead of --problem")
+	inputColumns := fs.String("inputs", "", "comma separated --dataset input columns, by name or 0-based index (default all but the targets)")
+	targetColumns := fs.String("targets", "", "comma separated --dataset target columns, by name or 0-based index (default the last)")
+	header := fs.Bool("header", false, "the first --dataset row names the columns")
+	scale := fs.Float64("scale", 1, "multiply --dataset values by this before rounding them to integers")
+	testFraction := fs.Float64("test-fraction", 0, "fraction of --dataset rows held out to validate the best form on")
+	splitSeed := fs.Int64("split-seed", 0, "shuffle --dataset rows with this seed before the test split (0 keeps file order)")
+	scoring := fs.String("scoring", "", "score outputs by one of "+strings.Join(evo.ScoringNames(), ", ")+" (default the problem's own)")
+	penalizeExtra := fs.Bool("penalize-extra", false, "penalize non-zero outputs past the answer's")
+	curriculum := fs.String("curriculum", "", "comma separated problems to evolve one population through in order, instead of --problem")
+	stageIterations := fs.Int("stage-iterations", 0, "advance from a --curriculum stage after N iterations even if unsolved (0 for no limit)")
+	configFlags(fs, &c)
+
+	if rest, err := parseArgs(fs, args); err != nil {
+		return EXITUSAGE
+	} else if len(rest) > 0 {
+		fmt.Fprintf(os.Stderr, "run: unexpected argument %q\n", rest[0])
+		return EXITUSAGE
+	}
+	problemSet := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "target-score":
+			useTarget = true
+		case "problem":
+			problemSet = true
+		}
+	})
+
+	var problem evo.ProblemInterface
+	var data *evo.DatasetProblem
+	var stages []evo.Stage
+	if *curriculum != "" {
+		if problemSet || *dataset != "" {
+			fmt.Fprintln(os.Stderr, "run: --curriculum can't be combined with --problem or --dataset")
+			return EXITUSAGE
+		}
+		for _, name := range strings.Split(*curriculum, ",") {
+			name = strings.TrimSpace(name)
+			p, err := resolveProblem(name)
+			if err == nil {
+				p, err = evo.WithScoring(p, *scoring, *penalizeExtra)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "run: %v; see evogo list-problems\n", err)
+				return EXITUSAGE
+			}
+			stage := evo.Stage{Name: name, Problem: p}
+			if *stageIterations > 0 {
+				stage.Until = evo.AnyOf(evo.SolvedAndStable(), evo.MaxIterations(*stageIterations))
+			}
+			stages = append(stages, stage)
+		}
+		// Saved programs are for the last stage's problem.
+		*problemName = stages[len(stages)-1].Name
+		problem = stages[len(stages)-1].Problem
+	} else if *dataset != "" {
+		if problemSet {
+			fmt.Fprintln(os.Stderr, "run: --dataset can't be combined with --problem")
+			return EXITUSAGE
+		}
+		dc := evo.DatasetConfig{
+			Header:       *header,
+			Scale:        *scale,
+			TestFraction: *testFraction,
+			Seed:         *splitSeed,
+		}
+		if *inputColumns != "" {
+			dc.Inputs = strings.Split(*inputColumns, ",")
+		}
+		if *targetColumns != "" {
+			dc.Targets = strings.Split(*targetColumns, ",")
+		}
+		d, err := evo.DatasetProblemFromFile(*dataset, dc)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITUSAGE
+		}
+		fmt.Printf("Dataset: %d training rows, %d test rows; inputs %s, targets %s\n", len(d.Train()), len(d.Test()),
+			strings.Join(d.InputColumns, ","), strings.Join(d.TargetColumns, ","))
+		problem, data = d, d
+	} else {
+		p, err := resolveProblem(*problemName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "run: %v; see evogo list-problems\n", err)
+			return EXITUSAGE
+		}
+		problem = p
+	}
+	// Curriculum stages were wrapped as they were resolved.
+	if stages == nil {
+		var err error
+		problem, err = evo.WithScoring(problem, *scoring, *penalizeExtra)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "run: %v\n", err)
+			return EXITUSAGE
+		}
+	}
+	if *resume && *checkpointDir == "" {
+		fmt.Fprintln(os.Stderr, "run: --resume needs --checkpoint-dir")
+		return EXITUSAGE
+	}
+
+	distributed := *remote != "" || *accept != ""
+	if (*islands > 1 || distributed || *mapElites) && *checkpointDir != "" {
+		fmt.Fprintln(os.Stderr, "run: checkpoints aren't supported with --islands, --remote, --accept or --map-elites")
+		return EXITUSAGE
+	}
+	if *islands > 1 && distributed {
+		fmt.Fprintln(os.Stderr, "run: --islands can't be combined with --remote or --accept; each worker hosts an island")
+		return EXITUSAGE
+	}
+	if *mapElites && (*islands > 1 || distributed) {
+		fmt.Fprintln(os.Stderr, "run: --map-elites can't be combined with --islands, --remote or --accept")
+		return EXITUSAGE
+	}
+	if *dataset != "" && distributed {
+		fmt.Fprintln(os.Stderr, "run: --dataset can't be combined with --remote or --accept")
+		return EXITUSAGE
+	}
+	if (*scoring != "" || *penalizeExtra) && distributed {
+		fmt.Fprintln(os.Stderr, "run: --scoring and --penalize-extra can't be combined with --remote or --accept")
+		return EXITUSAGE
+	}
+	if stages != nil && (*islands > 1 || distributed || *mapElites || *checkpointDir != "") {
+		fmt.Fprintln(os.Stderr, "run: --curriculum can't be combined with --islands, --remote, --accept, --map-elites or checkpoints")
+		return EXITUSAGE
+	}
+	if *gridFile != "" && !*mapElites {
+		fmt.Fprintln(os.Stderr, "run: --grid needs --map-elites")
+		return EXITUSAGE
+	}
+
+	var r runner
+	var seed int64
+	var grid *evo.MapElites
+	var cu *evo.Curriculum
+	if stages != nil {
+		var err error
+		cu, err = evo.NewCurriculum(stages, c)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+		r, seed = cu, cu.Config().Seed
+	} else if *mapElites {
+		mc := evo.DefaultMapElitesConfig(c)
+		mc.Probes = *probes
+		axes, err := parseFeatures(*features, c)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITUSAGE
+		}
+		mc.Features = axes
+
+		m, err := evo.NewMapElites(problem, mc)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+		if data != nil && len(data.Test()) > 0 {
+			m.SetCases(nil, data.Test())
+		}
+		r, seed, grid = m, m.Config().Config.Seed, m
+	} else if distributed {
+		cc := evo.DefaultCoordinatorConfig(*problemName, c)
+		cc.MigrationInterval = *migrationInterval
+		cc.Migrants = *migrants
+		cc.Topology = *topology
+
+		co, err := evo.NewCoordinator(cc)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+		defer co.Close()
+
+		if *remote != "" {
+			for _, addr := range strings.Split(*remote, ",") {
+				if err := co.Dial(strings.TrimSpace(addr)); err != nil {
+					fmt.Fprintln(os.Stderr, "run:", err)
+					return EXITERROR
+				}
+			}
+		}
+		if *accept != "" {
+			network, address := evo.ParseAddr(*accept)
+			l, err := net.Listen(network, address)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "run:", err)
+				return EXITERROR
+			}
+			defer l.Close()
+			go co.Accept(l)
+			fmt.Println("Accepting workers on", l.Addr())
+		}
+		r, seed = co, co.Config().Seed
+	} else if *islands > 1 {
+		ac := evo.DefaultArchipelagoConfig(c, *islands)
+		ac.MigrationInterval = *migrationInterval
+		ac.Migrants = *migrants
+		ac.Topology = *topology
+		if *islandSelections != "" {
+			names := strings.Split(*islandSelections, ",")
+			for i := range ac.Islands {
+				ac.Islands[i].Selection = strings.TrimSpace(names[i%len(names)])
+			}
+		}
+
+		a, err := evo.NewArchipelago(problem, ac)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+		if data != nil && len(data.Test()) > 0 {
+			for i := 0; i < a.Len(); i++ {
+				a.Island(i).SetCases(nil, data.Test())
+			}
+		}
+		r, seed = a, a.Config().Seed
+	} else {
+		var e evo.Evolver
+		var err error
+		if *resume {
+			e, err = evo.ResumeEvolver(*checkpointDir, *checkpointEvery, problem)
+		} else {
+			e, err = evo.NewEvolver(problem, c)
+			if err == nil && *checkpointDir != "" {
+				e.SetCheckpointDir(*checkpointDir, *checkpointEvery)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+		if data != nil && len(data.Test()) > 0 {
+			e.SetCases(nil, data.Test())
+		}
+		r, seed = &e, e.Config().Seed
+	}
+
+	if *events != "" {
+		file, err := os.Create(*events)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+		defer file.Close()
+		r.AddObserver(evo.NewJSONLinesObserver(file))
+	}
+
+	// A run always ends once solved and stable, or a curriculum once its
+	// last stage advances; the flags add further ways to stop.
+	var stops []evo.StopCondition
+	if cu == nil {
+		stops = append(stops, evo.SolvedAndStable())
+	}
+	if *maxIterations > 0 {
+		stops = append(stops, evo.MaxIterations(*maxIterations))
+	}
+	if *timeout > 0 {
+		stops = append(stops, evo.WallClock(*timeout))
+	}
+	if *maxEvals > 0 {
+		stops = append(stops, evo.MaxEvaluations(*maxEvals))
+	}
+	if useTarget {
+		stops = append(stops, evo.TargetScore(*targetScore))
+	}
+	if *stagnation > 0 {
+		stops = append(stops, evo.Stagnation(*stagnation))
+	}
+	r.StopWhen(stops...)
+	r.AddObserver(evo.NewStdoutObserver(os.Stdout))
+
+	// Ctrl-C finishes the current iteration and stops cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Println("Seed:", seed)
+	res, _ := r.Run(ctx)
+	fmt.Printf("Stopped after %d iterations (%v): %s\n", res.Iterations, res.Elapsed.Round(time.Millisecond), res.Reason)
+	if res.Validated {
+		fmt.Printf("Training score %f, validation score %f\n", res.Score, res.ValidationScore)
+	}
+
+	if cu != nil {
+		for _, st := range cu.Results() {
+			fmt.Printf("Stage %s: score %f cost %f after %d iterations: %s\n", st.Name, st.Score, st.Cost, st.Iterations, st.Reason)
+		}
+	}
+
+	for _, p := range res.ParetoFront {
+		fmt.Printf("Pareto front: score %f cost %f length %d\n", p.Score, p.Cost, p.Length)
+	}
+	for i, en := range res.HallOfFame {
+		fmt.Printf("Hall of fame %d: score %f cost %f found in iteration %d\n", i+1, en.Score, en.Cost, en.Generation)
+	}
+	if grid != nil {
+		fmt.Printf("Grid: %d of %d cells filled\n", grid.Len(), grid.Cells())
+		if *gridFile != "" {
+			if err := writeGrid(*gridFile, grid); err != nil {
+				fmt.Fprintln(os.Stderr, "run:", err)
+				return EXITERROR
+			}
+		}
+	}
+
+	if *out != "" {
+		if err := saveProgram(*out, *problemName, res.Best); err != nil {
+			fmt.Fprintln(os.Stderr, "run:", err)
+			return EXITERROR
+		}
+	}
 
-	e := evo.NewEvolver(problem)
+	if !res.Solved {
+		return EXITUNSOLVED
+	}
+	return EXITSOLVED
+}
+
+// Grid axes from a --features list of NAME or NAME:BINS.  Axes span every
+// value the feature can take under c.
+func parseFeatures(list string, c evo.Config) ([]evo.FeatureAxis, error) {
+	var axes []evo.FeatureAxis
+	for _, field := range strings.Split(list, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), ":", 2)
+		a, err := evo.NewFeatureAxis(parts[0], c)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 2 {
+			if a.Bins, err = strconv.Atoi(parts[1]); err != nil {
+				return nil, fmt.Errorf("feature %q: bad bin count %q", parts[0], parts[1])
+			}
+		}
+		axes = append(axes, a)
+	}
+	return axes, nil
+}
+
+func writeGrid(path string, m *evo.MapElites) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.WriteGrid(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write the form's program with a header describing where it came from.
+func saveProgram(path string, problemName string, f evo.Form) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(file, "# problem: %s\n# score: %f cost: %f\n", problemName, f.AvgScore(), f.AvgCost())
+	if err := evo.Disassemble(f, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func loadProgram(path string, c *evo.Config) (evo.Form, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return evo.Form{}, err
+	}
+	defer file.Close()
+
+	f, err := evo.AssembleWithConfig(file, c)
+	if err != nil {
+		return evo.Form{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+func listProblemsCmd(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "list-problems: takes no arguments")
+		return EXITUSAGE
+	}
+
+	// Columns are as wide as their longest entry.
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, info := range evo.List() {
+		fmt.Fprintf(w, "%s\t%s\t%d in %d out\t%s\n", info.Name, info.Difficulty, info.Inputs, info.Outputs, info.Description)
+	}
+	w.Flush()
+	return EXITSOLVED
+}
+
+func disasmCmd(args []string) int {
+	c := evo.DefaultConfig()
+	fs := flag.NewFlagSet("disasm", flag.ContinueOnError)
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return EXITUSAGE
+	}
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: evogo disasm FILE")
+		return EXITUSAGE
+	}
+
+	f, err := loadProgram(rest[0], &c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "disasm:", err)
+		return EXITERROR
+	}
 
-	e.RunAndReport()
+	for i, ins := range f.Instructions() {
+		fmt.Printf("%3d : %s\n", i, c.InstructionSet.Describe(ins))
+	}
+	return EXITSOLVED
+}
+
+func execCmd(args []string) int {
+	c := evo.DefaultConfig()
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	inputFlag := fs.String("input", "", "comma separated program input, e.g. 3,4")
+	fs.IntVar(&c.MemSize, "mem-size", c.MemSize, "memory cells")
+	fs.IntVar(&c.IOSize, "io-size", c.IOSize, "output cells")
+	fs.IntVar(&c.MaxOps, "max-ops", c.MaxOps, "instructions executed before the program is stopped")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return EXITUSAGE
+	}
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: evogo exec FILE --input 3,4")
+		return EXITUSAGE
+	}
+	if err := c.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "exec:", err)
+		return EXITUSAGE
+	}
+
+	input, err := parseInts(*inputFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "exec: --input:", err)
+		return EXITUSAGE
+	}
+
+	f, err := loadProgram(rest[0], &c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "exec:", err)
+		return EXITERROR
+	}
+
+	printInts(os.Stdout, f.Run(input))
+	return EXITSOLVED
+}
+
+func workerCmd(args []string) int {
+	fs := flag.NewFlagSet("worker", flag.ContinueOnError)
+	listen := fs.String("listen", "", "address to serve coordinators on, e.g. localhost:7000 or unix:/tmp/evogo.sock")
+	join := fs.String("join", "", "address of a coordinator started with run --accept")
+
+	if rest, err := parseArgs(fs, args); err != nil {
+		return EXITUSAGE
+	} else if len(rest) > 0 || (*listen == "") == (*join == "") {
+		fmt.Fprintln(os.Stderr, "usage: evogo worker --listen ADDR | --join ADDR")
+		return EXITUSAGE
+	}
+
+	w := evo.NewWorker(resolveProblem)
+	if *join != "" {
+		if err := w.Join(*join); err != nil {
+			fmt.Fprintln(os.Stderr, "worker:", err)
+			return EXITERROR
+		}
+		return EXITSOLVED
+	}
+
+	network, address := evo.ParseAddr(*listen)
+	l, err := net.Listen(network, address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "worker:", err)
+		return EXITERROR
+	}
+	fmt.Println("Serving on", l.Addr())
+	if err := w.Serve(l); err != nil {
+		fmt.Fprintln(os.Stderr, "worker:", err)
+		return EXITERROR
+	}
+	return EXITSOLVED
+}
+
+// The problem named on the command line: a built-in problem or a problem
+// file.  Workers resolve file names on their own machine.
+func resolveProblem(name string) (evo.ProblemInterface, error) {
+	if strings.HasSuffix(name, ".json") {
+		return evo.ProblemFromFile(name)
+	}
+	info, err := evo.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return info.Problem, nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var values []int
+	if strings.TrimSpace(s) == "" {
+		return values, nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
 
-	fmt.Println("all done")
+func printInts(w io.Writer, values []int) {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	fmt.Fprintln(w, strings.Join(strs, ","))
 }
@@ -0,0 +1,196 @@
+package evo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Text format for Form programs.  One instruction per line:
+//
+//	# Copy input0 to output0.
+//	start:
+//		copyin 0 0     ; mem0 = input0
+//		copyres 0 0
+//		endexec
+//
+// Comments run from '#' or ';' to the end of the line.  A label is a name
+// followed by ':' and may stand on its own line or precede an instruction; it
+// refers to the index of the next instruction.  Any parameter may be an
+// integer or a label.  The ".word op p1 p2 p3 p4" directive writes a raw
+// instruction, which is how the disassembler preserves invalid opcodes.
+
+// An error in assembler input.
+type AsmError struct {
+	Line int
+	Msg  string
+}
+
+func (e *AsmError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Msg
+}
+
+// Assemble a program for the DefaultConfig and DefaultInstructionSet.
+func Assemble(r io.Reader) (Form, error) {
+	c := DefaultConfig()
+	return AssembleWithConfig(r, &c)
+}
+
+type asmLine struct {
+	line   int
+	fields []string
+}
+
+// Assemble a program for the configuration's memory sizes and instruction
+// set.  The program's length may differ from CodeSize.
+func AssembleWithConfig(r io.Reader, c *Config) (Form, error) {
+	isa := c.instructionSet()
+	labels := map[string]int{}
+	var lines []asmLine
+
+	// First pass: strip comments, record label positions.
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := scanner.Text()
+		if i := strings.IndexAny(text, "#;"); i >= 0 {
+			text = text[:i]
+		}
+
+		for {
+			text = strings.TrimSpace(text)
+			colon := strings.Index(text, ":")
+			if colon < 0 {
+				break
+			}
+			label := strings.TrimSpace(text[:colon])
+			if !validLabel(label) {
+				return Form{}, &AsmError{lineNo, fmt.Sprintf("invalid label %q", label)}
+			}
+			if _, dup := labels[label]; dup {
+				return Form{}, &AsmError{lineNo, fmt.Sprintf("duplicate label %q", label)}
+			}
+			labels[label] = len(lines)
+			text = text[colon+1:]
+		}
+
+		if fields := strings.Fields(text); len(fields) > 0 {
+			lines = append(lines, asmLine{lineNo, fields})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Form{}, err
+	}
+
+	// Second pass: encode instructions.
+	f := Form{cfg: c}
+	f.init()
+
+	for _, l := range lines {
+		name, args := l.fields[0], l.fields[1:]
+
+		var op int
+		var arity int
+		if name == ".word" {
+			if len(args) < 1 || len(args) > 5 {
+				return Form{}, &AsmError{l.line, fmt.Sprintf(".word expects 1 to 5 values, got %d", len(args))}
+			}
+			v, err := asmValue(args[0], labels)
+			if err != nil {
+				return Form{}, &AsmError{l.line, err.Error()}
+			}
+			op, args, arity = v, args[1:], len(args)-1
+		} else {
+			code, ok := isa.Opcode(name)
+			if !ok {
+				return Form{}, &AsmError{l.line, fmt.Sprintf("unknown operation %q", name)}
+			}
+			operation, _ := isa.Lookup(code)
+			if len(args) != operation.Arity {
+				return Form{}, &AsmError{l.line, fmt.Sprintf("%s expects %d parameters, got %d", name, operation.Arity, len(args))}
+			}
+			op, arity = code, operation.Arity
+		}
+
+		ins := Instruction{operation: op}
+		for n := 1; n <= arity; n++ {
+			v, err := asmValue(args[n-1], labels)
+			if err != nil {
+				return Form{}, &AsmError{l.line, err.Error()}
+			}
+			ins.setParam(n, v)
+		}
+
+		f.instructions = append(f.instructions, ins)
+	}
+
+	return f, nil
+}
+
+func validLabel(label string) bool {
+	if label == "" {
+		return false
+	}
+	for i, c := range label {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		digit := c >= '0' && c <= '9'
+		if !letter && !(digit && i > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func asmValue(token string, labels map[string]int) (int, error) {
+	if v, err := strconv.Atoi(token); err == nil {
+		return v, nil
+	}
+	if !validLabel(token) {
+		return 0, fmt.Errorf("invalid parameter %q", token)
+	}
+	v, ok := labels[token]
+	if !ok {
+		return 0, fmt.Errorf("undefined label %q", token)
+	}
+	return v, nil
+}
+
+// Write the form's program in the Assemble format.  Code addresses used as
+// jump targets (see Operation.Target) are written as labels.
+func Disassemble(f Form, w io.Writer) error {
+	targets := map[int]bool{}
+	for _, ins := range f.instructions {
+		if op, ok := f.cfg.InstructionSet.Lookup(ins.operation); ok && op.Target > 0 {
+			if t := ins.Param(op.Target); t >= 0 && t < len(f.instructions) {
+				targets[t] = true
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for i, ins := range f.instructions {
+		if targets[i] {
+			fmt.Fprintf(bw, "L%d:\n", i)
+		}
+
+		op, ok := f.cfg.InstructionSet.Lookup(ins.operation)
+		if !ok {
+			fmt.Fprintf(bw, "\t.word %d %d %d %d %d\n", ins.operation, ins.p1, ins.p2, ins.p3, ins.p4)
+			continue
+		}
+
+		line := op.Name
+		for n := 1; n <= op.Arity; n++ {
+			v := ins.Param(n)
+			if n == op.Target && targets[v] {
+				line += " L" + strconv.Itoa(v)
+			} else {
+				line += " " + strconv.Itoa(v)
+			}
+		}
+		fmt.Fprintf(bw, "\t%s\n", line)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,89 @@
+package evo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssembleCopy(t *testing.T) {
+	src := `
+# Copy input1 to output0.
+	copyin 1 0   ; mem0 = input1
+	copyres 0 0
+	endexec
+`
+	f, err := Assemble(strings.NewReader(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, len(f.instructions))
+	assert.Equal(t, NewInstruction(COPYIN, 1, 0), f.instructions[0])
+
+	output := f.Run([]int{66, 77, 88})
+	assert.Equal(t, 77, output[0])
+}
+
+func TestAssembleLabels(t *testing.T) {
+	// Count mem0 up to input0 and output it.
+	src := `
+	copyin 0 1
+loop: inceq 0 1 done
+	jump loop
+done:
+	copyres 0 0
+	endexec
+`
+	f, err := Assemble(strings.NewReader(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, NewInstruction(INCEQ, 0, 1, 3), f.instructions[1])
+	assert.Equal(t, NewInstruction(JUMP, 1), f.instructions[2])
+
+	output := f.Run([]int{3})
+	assert.Equal(t, 3, output[0])
+}
+
+func TestAssembleErrors(t *testing.T) {
+	cases := map[string]string{
+		"noop\nfrob 1 2":    "line 2: unknown operation \"frob\"",
+		"copyin 1":          "line 1: copyin expects 2 parameters, got 1",
+		"\n\njump nowhere":  "line 3: undefined label \"nowhere\"",
+		"a: noop\na: noop":  "line 2: duplicate label \"a\"",
+		"setval 1 2x":       "line 1: invalid parameter \"2x\"",
+		"9lives: noop":      "line 1: invalid label \"9lives\"",
+		".word 1 2 3 4 5 6": "line 1: .word expects 1 to 5 values, got 6",
+	}
+
+	for src, msg := range cases {
+		_, err := Assemble(strings.NewReader(src))
+		if assert.Error(t, err, src) {
+			assert.Equal(t, msg, err.Error())
+		}
+	}
+}
+
+func TestDisassembleRoundTrip(t *testing.T) {
+	f := NewRandomForm(testConfig(), testRand())
+	f.instructions[0] = NewInstruction(JUMP, 4)
+	f.instructions[1] = NewInstruction(57, 1, 2, 3, 4) // Invalid opcode.
+
+	var buf bytes.Buffer
+	require.NoError(t, Disassemble(f, &buf))
+
+	assert.Contains(t, buf.String(), "\tjump L4\n")
+	assert.Contains(t, buf.String(), "L4:\n")
+	assert.Contains(t, buf.String(), "\t.word 57 1 2 3 4\n")
+
+	g, err := Assemble(&buf)
+	require.NoError(t, err)
+	require.Equal(t, len(f.instructions), len(g.instructions))
+
+	// Parameters beyond an operation's arity are not significant and are
+	// not preserved.
+	for i := range f.instructions {
+		assert.Equal(t, f.cfg.InstructionSet.Mnemonic(f.instructions[i]), g.cfg.InstructionSet.Mnemonic(g.instructions[i]))
+	}
+}
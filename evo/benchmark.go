@@ -0,0 +1,274 @@
+package evo
+
+import (
+	"math/rand"
+)
+
+// Classic program synthesis benchmarks.  Unlike the built-in problems, most
+// need branches or loops, so they make a standard suite to compare evolver
+// changes on.  All score like Problem.
+
+// Length of the arrays of the array problems.  Their input0 is the number of
+// elements in use, 1 to ARRAYLENGTH, followed by ARRAYLENGTH elements;
+// those past the length are junk a program has to ignore.
+const ARRAYLENGTH = 8
+
+// Largest n FibonacciProblem asks for.
+const FIBONACCIMAX = 20
+
+// Largest divisor DivModProblem draws.
+const DIVISORMAX = 20
+
+type AbsProblem struct{ Problem }
+type SumArrayProblem struct{ Problem }
+type CountNegativesProblem struct{ Problem }
+type Sort3Problem struct{ Problem }
+type GCDProblem struct{ Problem }
+type FibonacciProblem struct{ Problem }
+type DivModProblem struct{ Problem }
+type IsPrimeProblem struct{ Problem }
+
+// The largest of N inputs; 0 means 3.
+type MaxProblem struct {
+	Problem
+	N int
+}
+
+// The first N inputs in reverse order; 0 means 3.
+type ReverseProblem struct {
+	Problem
+	N int
+}
+
+// A uniform value in -PROBLEM_INPUT_RANGE..PROBLEM_INPUT_RANGE.
+func problemValue(rng *rand.Rand) int {
+	return rng.Intn(PROBLEM_INPUT_RANGE*2+1) - PROBLEM_INPUT_RANGE
+}
+
+func problemValues(rng *rand.Rand, n int) []int {
+	input := make([]int, n)
+	for i := range input {
+		input[i] = problemValue(rng)
+	}
+	return input
+}
+
+// An array problem's input: its length then ARRAYLENGTH values.
+func arrayInput(rng *rand.Rand) []int {
+	input := problemValues(rng, 1+ARRAYLENGTH)
+	input[0] = 1 + rng.Intn(ARRAYLENGTH)
+	return input
+}
+
+// The elements of an array problem's input in use.
+func arrayElements(input []int) []int {
+	n := input[0]
+	if n < 0 {
+		n = 0
+	}
+	if n > len(input)-1 {
+		n = len(input) - 1
+	}
+	return input[1 : 1+n]
+}
+
+// output0 = |input0|
+func (p AbsProblem) Answer(input []int) []int {
+	if input[0] < 0 {
+		return []int{-input[0]}
+	}
+	return []int{input[0]}
+}
+
+func (p AbsProblem) GenerateInputs(rng *rand.Rand) []int {
+	return problemValues(rng, 1)
+}
+
+func (p MaxProblem) n() int {
+	if p.N < 1 {
+		return 3
+	}
+	return p.N
+}
+
+// output0 = max(input0..N-1)
+func (p MaxProblem) Answer(input []int) []int {
+	max := input[0]
+	for _, v := range input[1:p.n()] {
+		if v > max {
+			max = v
+		}
+	}
+	return []int{max}
+}
+
+func (p MaxProblem) GenerateInputs(rng *rand.Rand) []int {
+	return problemValues(rng, p.n())
+}
+
+// output0 = the sum of the array.
+func (p SumArrayProblem) Answer(input []int) []int {
+	sum := 0
+	for _, v := range arrayElements(input) {
+		sum += v
+	}
+	return []int{sum}
+}
+
+func (p SumArrayProblem) GenerateInputs(rng *rand.Rand) []int {
+	return arrayInput(rng)
+}
+
+// output0 = the number of negative elements in the array.
+func (p CountNegativesProblem) Answer(input []int) []int {
+	count := 0
+	for _, v := range arrayElements(input) {
+		if v < 0 {
+			count++
+		}
+	}
+	return []int{count}
+}
+
+func (p CountNegativesProblem) GenerateInputs(rng *rand.Rand) []int {
+	return arrayInput(rng)
+}
+
+// output0..2 = input0..2 in ascending order.
+func (p Sort3Problem) Answer(input []int) []int {
+	a, b, c := input[0], input[1], input[2]
+	if a > b {
+		a, b = b, a
+	}
+	if b > c {
+		b, c = c, b
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return []int{a, b, c}
+}
+
+// Values from a small range so some repeat.
+func (p Sort3Problem) GenerateInputs(rng *rand.Rand) []int {
+	input := make([]int, 3)
+	for i := range input {
+		input[i] = rng.Intn(41) - 20
+	}
+	return input
+}
+
+// output0 = the greatest common divisor of input0 and input1.
+func (p GCDProblem) Answer(input []int) []int {
+	a, b := input[0], input[1]
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return []int{a}
+}
+
+// Two positive multiples of a common factor, so the answer is seldom 1.
+func (p GCDProblem) GenerateInputs(rng *rand.Rand) []int {
+	k := 1 + rng.Intn(12)
+	return []int{k * (1 + rng.Intn(12)), k * (1 + rng.Intn(12))}
+}
+
+// output0 = Fibonacci(input0), where Fibonacci(0) = 0 and Fibonacci(1) = 1.
+func (p FibonacciProblem) Answer(input []int) []int {
+	a, b := 0, 1
+	for i := 0; i < input[0]; i++ {
+		a, b = b, a+b
+	}
+	return []int{a}
+}
+
+func (p FibonacciProblem) GenerateInputs(rng *rand.Rand) []int {
+	return []int{rng.Intn(FIBONACCIMAX + 1)}
+}
+
+// output0 = input0 / input1, output1 = input0 % input1, truncating like Go.
+// Division by zero gives 0 and 0.
+func (p DivModProblem) Answer(input []int) []int {
+	if input[1] == 0 {
+		return []int{0, 0}
+	}
+	return []int{input[0] / input[1], input[0] % input[1]}
+}
+
+func (p DivModProblem) GenerateInputs(rng *rand.Rand) []int {
+	return []int{problemValue(rng), 1 + rng.Intn(DIVISORMAX)}
+}
+
+// output0 = 1 if input0 is prime, otherwise 0.
+func (p IsPrimeProblem) Answer(input []int) []int {
+	if isPrime(input[0]) {
+		return []int{1}
+	}
+	return []int{0}
+}
+
+// Numbers in 0..PROBLEM_INPUT_RANGE, half of them prime so always answering
+// 0 doesn't pay.
+func (p IsPrimeProblem) GenerateInputs(rng *rand.Rand) []int {
+	prime := rng.Intn(2) == 0
+	for {
+		n := rng.Intn(PROBLEM_INPUT_RANGE + 1)
+		if isPrime(n) == prime {
+			return []int{n}
+		}
+	}
+}
+
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (p ReverseProblem) n() int {
+	if p.N < 1 {
+		return 3
+	}
+	return p.N
+}
+
+// output0..N-1 = inputN-1..0
+func (p ReverseProblem) Answer(input []int) []int {
+	n := p.n()
+	answer := make([]int, n)
+	for i := range answer {
+		answer[i] = input[n-1-i]
+	}
+	return answer
+}
+
+func (p ReverseProblem) GenerateInputs(rng *rand.Rand) []int {
+	return problemValues(rng, p.n())
+}
+
+func init() {
+	mustRegister(
+		ProblemInfo{"abs", "output0 = |input0|", 1, 1, DIFFICULTYMEDIUM, AbsProblem{}},
+		ProblemInfo{"max4", "output0 = max(input0..3)", 4, 1, DIFFICULTYMEDIUM, MaxProblem{N: 4}},
+		ProblemInfo{"sum-array", "output0 = input1 + ... + input(input0)", 1 + ARRAYLENGTH, 1, DIFFICULTYHARD, SumArrayProblem{}},
+		ProblemInfo{"count-negatives", "output0 = how many of input1..input(input0) are negative", 1 + ARRAYLENGTH, 1, DIFFICULTYHARD, CountNegativesProblem{}},
+		ProblemInfo{"sort3", "output0..2 = input0..2 sorted ascending", 3, 3, DIFFICULTYHARD, Sort3Problem{}},
+		ProblemInfo{"gcd", "output0 = gcd(input0, input1)", 2, 1, DIFFICULTYHARD, GCDProblem{}},
+		ProblemInfo{"fibonacci", "output0 = fibonacci(input0)", 1, 1, DIFFICULTYHARD, FibonacciProblem{}},
+		ProblemInfo{"divmod", "output0 = input0 / input1, output1 = input0 % input1", 2, 2, DIFFICULTYHARD, DivModProblem{}},
+		ProblemInfo{"is-prime", "output0 = 1 if input0 is prime, else 0", 1, 1, DIFFICULTYHARD, IsPrimeProblem{}},
+		ProblemInfo{"reverse4", "output0..3 = input3..0", 4, 4, DIFFICULTYMEDIUM, ReverseProblem{N: 4}},
+	)
+}
@@ -0,0 +1,88 @@
+package evo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchmarkAnswers(t *testing.T) {
+	tests := []struct {
+		problem ProblemInterface
+		input   []int
+		answer  []int
+	}{
+		{AbsProblem{}, []int{-7}, []int{7}},
+		{AbsProblem{}, []int{7}, []int{7}},
+		{MaxProblem{}, []int{1, 9, 3, 100}, []int{9}},
+		{MaxProblem{N: 4}, []int{1, 9, 3, 100}, []int{100}},
+		{MaxProblem{N: 2}, []int{-5, -8}, []int{-5}},
+		{SumArrayProblem{}, []int{3, 1, 2, 3, 50, 50, 50, 50, 50}, []int{6}},
+		{SumArrayProblem{}, []int{8, 1, 1, 1, 1, 1, 1, 1, -1}, []int{6}},
+		{CountNegativesProblem{}, []int{4, -1, 2, -3, -4, -5, -6, -7, -8}, []int{3}},
+		{CountNegativesProblem{}, []int{1, 5, -1, -1, -1, -1, -1, -1, -1}, []int{0}},
+		{Sort3Problem{}, []int{3, 1, 2}, []int{1, 2, 3}},
+		{Sort3Problem{}, []int{5, -5, 5}, []int{-5, 5, 5}},
+		{Sort3Problem{}, []int{9, 8, 7}, []int{7, 8, 9}},
+		{GCDProblem{}, []int{12, 18}, []int{6}},
+		{GCDProblem{}, []int{7, 13}, []int{1}},
+		{GCDProblem{}, []int{0, 4}, []int{4}},
+		{FibonacciProblem{}, []int{0}, []int{0}},
+		{FibonacciProblem{}, []int{1}, []int{1}},
+		{FibonacciProblem{}, []int{10}, []int{55}},
+		{DivModProblem{}, []int{17, 5}, []int{3, 2}},
+		{DivModProblem{}, []int{-17, 5}, []int{-3, -2}},
+		{DivModProblem{}, []int{17, 0}, []int{0, 0}},
+		{IsPrimeProblem{}, []int{0}, []int{0}},
+		{IsPrimeProblem{}, []int{2}, []int{1}},
+		{IsPrimeProblem{}, []int{91}, []int{0}},
+		{IsPrimeProblem{}, []int{97}, []int{1}},
+		{ReverseProblem{}, []int{1, 2, 3, 4}, []int{3, 2, 1}},
+		{ReverseProblem{N: 4}, []int{1, 2, 3, 4}, []int{4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.answer, tt.problem.Answer(tt.input), "%T%v", tt.problem, tt.input)
+	}
+}
+
+func TestBenchmarkInputs(t *testing.T) {
+	rng := testRand()
+	primes := 0
+	for i := 0; i < 500; i++ {
+		abs := AbsProblem{}.GenerateInputs(rng)
+		require.Equal(t, 1, len(abs))
+		assert.True(t, abs[0] >= -PROBLEM_INPUT_RANGE && abs[0] <= PROBLEM_INPUT_RANGE)
+
+		assert.Equal(t, 3, len(MaxProblem{}.GenerateInputs(rng)))
+		assert.Equal(t, 5, len(ReverseProblem{N: 5}.GenerateInputs(rng)))
+
+		array := SumArrayProblem{}.GenerateInputs(rng)
+		require.Equal(t, 1+ARRAYLENGTH, len(array))
+		assert.True(t, array[0] >= 1 && array[0] <= ARRAYLENGTH)
+
+		gcd := GCDProblem{}.GenerateInputs(rng)
+		assert.True(t, gcd[0] > 0 && gcd[1] > 0)
+
+		fib := FibonacciProblem{}.GenerateInputs(rng)
+		assert.True(t, fib[0] >= 0 && fib[0] <= FIBONACCIMAX)
+
+		div := DivModProblem{}.GenerateInputs(rng)
+		assert.True(t, div[1] >= 1 && div[1] <= DIVISORMAX)
+
+		prime := IsPrimeProblem{}.GenerateInputs(rng)
+		assert.True(t, prime[0] >= 0 && prime[0] <= PROBLEM_INPUT_RANGE)
+		primes += IsPrimeProblem{}.Answer(prime)[0]
+	}
+	assert.InDelta(t, 250.0, float64(primes), 50)
+}
+
+func TestBenchmarksRegistered(t *testing.T) {
+	for _, name := range []string{"abs", "max4", "sum-array", "count-negatives", "sort3", "gcd", "fibonacci", "divmod", "is-prime", "reverse4"} {
+		info, err := Lookup(name)
+		require.NoError(t, err)
+		input := info.Problem.GenerateInputs(testRand())
+		assert.Equal(t, info.Inputs, len(input), name)
+		assert.Equal(t, info.Outputs, len(info.Problem.Answer(input)), name)
+	}
+}
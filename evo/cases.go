@@ -0,0 +1,75 @@
+package evo
+
+import (
+	"math/rand"
+)
+
+// A problem input and its expected answer.
+type Case struct {
+	Input  []int
+	Answer []int
+}
+
+// n cases with inputs drawn by the problem.
+func GenerateCases(p ProblemInterface, n int, rng *rand.Rand) []Case {
+	cases := make([]Case, n)
+	for i := range cases {
+		cases[i].Input = p.GenerateInputs(rng)
+		cases[i].Answer = p.Answer(cases[i].Input)
+	}
+	return cases
+}
+
+// Score forms on fixed cases rather than fresh random inputs.  Every form
+// of every iteration is scored on all the training cases, so scores compare
+// across generations, and a form scoring 0.0 only solves the problem if it
+// also scores 0.0 on the validation cases it never trained on.  A nil set is
+// generated as configured by Config.TrainingCases or ValidationCases.  Call
+// before running.
+func (e *Evolver) SetCases(training, validation []Case) {
+	e.training = training
+	e.validation = validation
+}
+
+// The training and validation cases, once the first iteration has generated
+// any that are configured.
+func (e *Evolver) Cases() (training, validation []Case) {
+	return e.training, e.validation
+}
+
+// Generate the configured case sets that weren't set.
+func (e *Evolver) drawCases() {
+	if (e.training != nil || e.cfg.TrainingCases == 0) && (e.validation != nil || e.cfg.ValidationCases == 0) {
+		return
+	}
+
+	rng := e.rng
+	if e.cfg.CaseSeed != 0 {
+		rng = rand.New(newSource(e.cfg.CaseSeed))
+	}
+	if e.training == nil && e.cfg.TrainingCases > 0 {
+		e.training = GenerateCases(e.problem, e.cfg.TrainingCases, rng)
+	}
+	if e.validation == nil && e.cfg.ValidationCases > 0 {
+		e.validation = GenerateCases(e.problem, e.cfg.ValidationCases, rng)
+	}
+}
+
+// Runs per form per iteration.
+func (e *Evolver) trials() int {
+	if len(e.training) > 0 {
+		return len(e.training)
+	}
+	return e.cfg.RaceTrials
+}
+
+// Mean score of f on the validation cases.  f's statistics are unchanged.
+func (e *Evolver) validate(f Form) float64 {
+	v := f.Clone()
+	sum := 0.0
+	for _, c := range e.validation {
+		v.runCode(&c.Input)
+		sum += e.problem.Score(c.Answer, v.output)
+	}
+	return sum / float64(len(e.validation))
+}
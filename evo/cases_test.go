@@ -0,0 +1,99 @@
+package evo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCases(t *testing.T) {
+	cases := GenerateCases(AdditionProblem{}, 5, testRand())
+	require.Equal(t, 5, len(cases))
+	for _, c := range cases {
+		assert.Equal(t, c.Input[0]+c.Input[1], c.Answer[0])
+	}
+}
+
+func TestEvolverTrainingCases(t *testing.T) {
+	evolver := func(seed int64) Evolver {
+		c := DefaultConfig()
+		c.Forms = 50
+		c.Seed = seed
+		c.TrainingCases = 5
+		c.ValidationCases = 3
+		c.CaseSeed = 7
+		e, err := NewEvolver(CopyProblem{}, c)
+		require.NoError(t, err)
+		return e
+	}
+
+	e := evolver(1)
+	e.step()
+	training, validation := e.Cases()
+	assert.Equal(t, 5, len(training))
+	assert.Equal(t, 3, len(validation))
+	assert.Equal(t, 5, e.forms[0].runCount)
+	assert.Equal(t, int64(50*5), e.evaluations)
+
+	// The case seed gives runs with other seeds the same cases, and they
+	// stay the same from one iteration to the next.
+	o := evolver(2)
+	o.step()
+	o.advance()
+	o.step()
+	ot, ov := o.Cases()
+	assert.Equal(t, training, ot)
+	assert.Equal(t, validation, ov)
+
+	// Cases survive a checkpoint.
+	var buf bytes.Buffer
+	require.NoError(t, e.Checkpoint(&buf))
+	r, err := LoadEvolver(&buf, CopyProblem{})
+	require.NoError(t, err)
+	rt, rv := r.Cases()
+	assert.Equal(t, training, rt)
+	assert.Equal(t, validation, rv)
+}
+
+func TestValidationRejectsOverfit(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 10
+	c.Seed = 1
+	e, err := NewEvolver(CopyProblem{}, c)
+	require.NoError(t, err)
+
+	// Training cases all have input0 = 1, so writing a constant 1 fits them.
+	training := GenerateCases(CopyProblem{}, 5, testRand())
+	for i := range training {
+		training[i].Input[0] = 1
+		training[i].Answer = CopyProblem{}.Answer(training[i].Input)
+	}
+	e.SetCases(training, GenerateCases(CopyProblem{}, 5, testRand()))
+
+	constant := NewNoopForm(e.cfg)
+	constant.instructions[0] = NewInstruction(SETVAL, 0, 1)
+	constant.instructions[1] = NewInstruction(COPYRES, 0, 0)
+	constant.instructions[2] = NewInstruction(ENDEXEC)
+	e.forms[0] = constant
+
+	rec := &recordingObserver{}
+	e.AddObserver(rec)
+	e.StopWhen(MaxIterations(1))
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, res.Score)
+	assert.True(t, res.Validated)
+	assert.True(t, res.ValidationScore < 0)
+	assert.False(t, res.Solved)
+	assert.True(t, rec.iterations[0].Validated)
+	assert.Equal(t, res.ValidationScore, rec.iterations[0].ValidationScore)
+
+	// A real copy program passes validation too.
+	e.forms[0] = NewCopyForm(e.cfg)
+	e.step()
+	assert.Equal(t, 0.0, e.validationScore)
+	assert.True(t, e.Solved())
+}
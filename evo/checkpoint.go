@@ -0,0 +1,268 @@
+package evo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Version of the checkpoint format written by Checkpoint.  Bump when the
+// format changes incompatibly.
+const CHECKPOINTVERSION = 2
+
+// Name of the checkpoint file written to the checkpoint directory.
+const CHECKPOINTFILE = "evolver.ckpt"
+
+// On-disk form of an Evolver.
+type checkpoint struct {
+	Version int
+
+	// Added in version 2; version 1 checkpoints ran with DefaultConfig.
+	Config *Config
+
+	// Operation names of the instruction set, in opcode order.  Checked on
+	// load so programs aren't silently reinterpreted by a different set.
+	Operations []string
+
+	Iteration           int
+	Solved              bool
+	SolvedNStable       bool
+	SameSolvedCostCount int
+	LastTopScore        float64
+	TopScore            float64
+	TopCost             float64
+	LastTopCost         float64
+	Evaluations         int64
+	ImprovedAt          int
+
+	RNGState uint64
+
+	Forms []FormRecord
+
+	// Entries of the hall of fame, best first.
+	HallOfFame []hallOfFameState `json:",omitempty"`
+
+	// Novelty search probes and archive.
+	Probes         [][]int     `json:",omitempty"`
+	NoveltyArchive [][]float64 `json:",omitempty"`
+
+	// Fixed training and validation cases.
+	Training   []Case `json:",omitempty"`
+	Validation []Case `json:",omitempty"`
+}
+
+// A form's program and statistics as written to checkpoints and sent to
+// remote workers.
+type FormRecord struct {
+	// Each instruction as [operation, p1, p2, p3, p4].
+	Instructions [][5]int
+
+	ScoreSum float64
+	RunCount int
+	CostSum  int
+
+	CaseScores []float64 `json:",omitempty"`
+}
+
+type hallOfFameState struct {
+	Form       FormRecord
+	Score      float64
+	Cost       float64
+	Generation int
+}
+
+func newFormRecord(f Form) FormRecord {
+	fs := FormRecord{
+		ScoreSum: f.scoreSum,
+		RunCount: f.runCount,
+		CostSum:  f.costSum,
+
+		CaseScores: f.caseScores,
+	}
+	for _, ins := range f.instructions {
+		fs.Instructions = append(fs.Instructions, [5]int{ins.operation, ins.p1, ins.p2, ins.p3, ins.p4})
+	}
+	return fs
+}
+
+func (fs FormRecord) form(c *Config) Form {
+	f := Form{cfg: c}
+	f.init()
+	for _, raw := range fs.Instructions {
+		f.instructions = append(f.instructions, NewInstruction(raw[0], raw[1:]...))
+	}
+	f.scoreSum = fs.ScoreSum
+	f.runCount = fs.RunCount
+	f.costSum = fs.CostSum
+	f.caseScores = fs.CaseScores
+	return f
+}
+
+// Write the full state of the evolver: every form with its statistics, the
+// evolver's bookkeeping and the random number generator state.
+func (e *Evolver) Checkpoint(w io.Writer) error {
+	c := checkpoint{
+		Version:             CHECKPOINTVERSION,
+		Config:              e.cfg,
+		Iteration:           e.iteration,
+		Solved:              e.solved,
+		SolvedNStable:       e.solvedNStable,
+		SameSolvedCostCount: e.sameSolvedCostCount,
+		LastTopScore:        e.lastTopScore,
+		TopScore:            e.topScore,
+		TopCost:             e.topCost,
+		LastTopCost:         e.lastTopCost,
+		Evaluations:         e.evaluations,
+		ImprovedAt:          e.improvedAt,
+		RNGState:            e.rngSource.state,
+		Probes:              e.probes,
+		NoveltyArchive:      e.noveltyArchive,
+		Training:            e.training,
+		Validation:          e.validation,
+	}
+
+	for i := 0; i < e.cfg.InstructionSet.Len(); i++ {
+		op, _ := e.cfg.InstructionSet.Lookup(i)
+		c.Operations = append(c.Operations, op.Name)
+	}
+
+	for _, f := range e.forms {
+		c.Forms = append(c.Forms, newFormRecord(f))
+	}
+	if e.hallOfFame != nil {
+		for _, en := range e.hallOfFame.Entries() {
+			c.HallOfFame = append(c.HallOfFame, hallOfFameState{newFormRecord(en.Form), en.Score, en.Cost, en.Generation})
+		}
+	}
+
+	return json.NewEncoder(w).Encode(c)
+}
+
+// Restore an evolver written by Checkpoint.  The problem and instruction set
+// aren't part of the checkpoint and must be supplied again.
+func LoadEvolver(r io.Reader, p ProblemInterface) (Evolver, error) {
+	return LoadEvolverWithInstructionSet(r, p, DefaultInstructionSet())
+}
+
+// Restore an evolver whose forms use a custom instruction set.
+func LoadEvolverWithInstructionSet(r io.Reader, p ProblemInterface, isa *InstructionSet) (Evolver, error) {
+	// Fields missing from older checkpoints keep their default values.
+	defaults := DefaultConfig()
+	c := checkpoint{Config: &defaults}
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return Evolver{}, fmt.Errorf("reading checkpoint: %v", err)
+	}
+	if c.Version < 1 || c.Version > CHECKPOINTVERSION {
+		return Evolver{}, fmt.Errorf("unsupported checkpoint version %d (want %d)", c.Version, CHECKPOINTVERSION)
+	}
+
+	if len(c.Operations) != isa.Len() {
+		return Evolver{}, fmt.Errorf("checkpoint has %d operations, instruction set has %d", len(c.Operations), isa.Len())
+	}
+	for code, name := range c.Operations {
+		if op, _ := isa.Lookup(code); op.Name != name {
+			return Evolver{}, fmt.Errorf("checkpoint opcode %d is %q, instruction set has %q", code, name, op.Name)
+		}
+	}
+
+	if c.Config == nil {
+		c.Config = &defaults
+	}
+	c.Config.InstructionSet = isa
+	if c.Config.Workers == 0 {
+		c.Config.Workers = runtime.GOMAXPROCS(0)
+	}
+	if err := c.Config.Validate(); err != nil {
+		return Evolver{}, err
+	}
+
+	e := Evolver{
+		problem:             p,
+		cfg:                 c.Config,
+		iteration:           c.Iteration,
+		solved:              c.Solved,
+		solvedNStable:       c.SolvedNStable,
+		sameSolvedCostCount: c.SameSolvedCostCount,
+		lastTopScore:        c.LastTopScore,
+		topScore:            c.TopScore,
+		topCost:             c.TopCost,
+		lastTopCost:         c.LastTopCost,
+		evaluations:         c.Evaluations,
+		improvedAt:          c.ImprovedAt,
+		probes:              c.Probes,
+		noveltyArchive:      c.NoveltyArchive,
+		training:            c.Training,
+		validation:          c.Validation,
+	}
+
+	for _, fs := range c.Forms {
+		e.forms = append(e.forms, fs.form(e.cfg))
+	}
+	if e.cfg.HallOfFame > 0 {
+		e.hallOfFame = NewHallOfFame(e.cfg.HallOfFame)
+		for _, hs := range c.HallOfFame {
+			e.hallOfFame.entries = append(e.hallOfFame.entries, HallOfFameEntry{hs.Form.form(e.cfg), hs.Score, hs.Cost, hs.Generation})
+		}
+	}
+
+	e.rngSource = &source{state: c.RNGState}
+	e.rng = rand.New(e.rngSource)
+
+	return e, nil
+}
+
+// Write a checkpoint to dir every n iterations of Run.  An empty dir
+// disables checkpointing.
+func (e *Evolver) SetCheckpointDir(dir string, n int) {
+	if n < 1 {
+		n = 1
+	}
+	e.checkpointDir = dir
+	e.checkpointEvery = n
+}
+
+// Write the checkpoint file to the checkpoint directory.  The file is
+// replaced atomically so a crash mid-write leaves the previous checkpoint.
+func (e *Evolver) saveCheckpoint() error {
+	if err := os.MkdirAll(e.checkpointDir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(e.checkpointDir, CHECKPOINTFILE+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := e.Checkpoint(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(e.checkpointDir, CHECKPOINTFILE))
+}
+
+// Resume from the checkpoint file in dir.  Checkpointing to dir continues
+// every n iterations.
+func ResumeEvolver(dir string, n int, p ProblemInterface) (Evolver, error) {
+	file, err := os.Open(filepath.Join(dir, CHECKPOINTFILE))
+	if err != nil {
+		return Evolver{}, err
+	}
+	defer file.Close()
+
+	e, err := LoadEvolver(file, p)
+	if err != nil {
+		return Evolver{}, err
+	}
+	e.SetCheckpointDir(dir, n)
+
+	return e, nil
+}
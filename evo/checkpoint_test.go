@@ -0,0 +1,78 @@
+package evo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	var problem CopyProblem
+
+	e := testEvolver(t, problem, 50, 1, 0)
+	e.runIteration()
+	e.doBookKeeping()
+	e.mutateFormsBucketStrategy(nil)
+	e.iteration = 1
+
+	var buf bytes.Buffer
+	require.NoError(t, e.Checkpoint(&buf))
+	saved := buf.Bytes()
+
+	r, err := LoadEvolver(bytes.NewReader(saved), problem)
+	require.NoError(t, err)
+
+	assert.Equal(t, e.iteration, r.iteration)
+	assert.Equal(t, e.topScore, r.topScore)
+	assert.Equal(t, e.cfg.Forms, r.cfg.Forms)
+	assert.Equal(t, e.cfg.Seed, r.cfg.Seed)
+	require.Equal(t, len(e.forms), len(r.forms))
+	for i := range e.forms {
+		assert.Equal(t, e.forms[i].instructions, r.forms[i].instructions)
+		assert.Equal(t, e.forms[i].scoreSum, r.forms[i].scoreSum)
+		assert.Equal(t, e.forms[i].runCount, r.forms[i].runCount)
+	}
+
+	// The resumed evolver continues exactly where the original left off.
+	e.runIteration()
+	e.mutateFormsBucketStrategy(nil)
+	r.runIteration()
+	r.mutateFormsBucketStrategy(nil)
+	for i := range e.forms {
+		assert.Equal(t, e.forms[i].instructions, r.forms[i].instructions)
+	}
+	assert.Equal(t, e.rng.Int63(), r.rng.Int63())
+}
+
+func TestCheckpointRejectsMismatch(t *testing.T) {
+	var problem CopyProblem
+	e := testEvolver(t, problem, 2, 1, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, e.Checkpoint(&buf))
+
+	isa := DefaultInstructionSet()
+	isa.MustRegister(Operation{Name: "extra", Exec: (*Form).noop})
+	_, err := LoadEvolverWithInstructionSet(bytes.NewReader(buf.Bytes()), problem, isa)
+	assert.Error(t, err)
+
+	_, err = LoadEvolver(bytes.NewReader([]byte(`{"Version": 99}`)), problem)
+	assert.Error(t, err)
+}
+
+func TestCheckpointDir(t *testing.T) {
+	var problem CopyProblem
+	dir := t.TempDir()
+
+	e := testEvolver(t, problem, 10, 1, 0)
+	e.SetCheckpointDir(dir, 1)
+	e.iteration = 7
+	require.NoError(t, e.saveCheckpoint())
+
+	r, err := ResumeEvolver(dir, 1, problem)
+	require.NoError(t, err)
+	assert.Equal(t, 7, r.iteration)
+	assert.Equal(t, 10, len(r.forms))
+}
@@ -0,0 +1,220 @@
+package evo
+
+import (
+	"fmt"
+)
+
+// Default values for Config.
+const MAXFORMS = 10000
+const STABILITYDURATION = 500
+const RACETRIALS = 20
+const CODESIZE = 10
+const MEMSIZE = 10
+const IOSIZE = 10
+const MAXOPS = 10
+const MUTATIONRATE = 50
+const TOURNAMENTSIZE = 3
+const RANKPRESSURE = 1.5
+const TRUNCATIONPERCENT = 20
+const NOVELTYK = 15
+const NOVELTYPROBES = 10
+const NOVELTYARCHIVE = 500
+
+// Config holds the tunable parameters of an Evolver and the forms it evolves.
+// Start from DefaultConfig and override fields as needed.
+type Config struct {
+	// Number of forms in the population.
+	Forms int
+
+	// Iterations the solved top cost must hold before the run is stable.
+	StabilityDuration int
+
+	// Fresh problem inputs each form is scored on per iteration, when there's
+	// no fixed training set.
+	RaceTrials int
+
+	// Instructions per form.
+	CodeSize int
+
+	// Memory cells per form.
+	MemSize int
+
+	// Output cells per form.
+	IOSize int
+
+	// Maximum instructions executed per run of a form.
+	MaxOps int
+
+	// Odds (1 in MutationRate) of each mutation happening.
+	MutationRate int
+
+	// Random seed.  0 seeds from the clock.
+	Seed int64
+
+	// Chance (0 to 1) that a child is bred by crossing its parent with a
+	// second parent before mutation.  0 disables crossover.
+	CrossoverRate float64
+
+	// Crossover operator, see CrossoverNames.
+	Crossover string
+
+	// How parents are chosen, see SelectionNames.
+	Selection string
+
+	// Forms drawn per tournament by "tournament" selection.
+	TournamentSize int
+
+	// Selective pressure (1 to 2) of "rank" selection.
+	RankPressure float64
+
+	// Percentage of forms that breed under "truncation" selection.
+	TruncationPercent float64
+
+	// Best forms copied unchanged into each next generation.
+	Elites int
+
+	// Distinct programs kept by the Evolver's HallOfFame.  0 keeps none.
+	HallOfFame int
+
+	// Weight (0 to 1) of novelty against score when selecting parents.  0
+	// selects on score alone, 1 is pure novelty search.  Lexicase and NSGA-II
+	// don't blend; any Novelty above 0 adds it as one more case or objective.
+	Novelty float64
+
+	// Nearest neighbours a form's novelty is measured against.
+	NoveltyK int
+
+	// Probe inputs whose outputs make up a form's behavior.
+	NoveltyProbes int
+
+	// Past novel behaviors kept to measure novelty against.
+	NoveltyArchive int
+
+	// Size of a fixed training set every form is scored on each iteration
+	// instead of RaceTrials fresh inputs.  0 draws fresh inputs.
+	TrainingCases int
+
+	// Size of a held-out validation set a solution must also solve before
+	// the run counts as solved.  0 for none.
+	ValidationCases int
+
+	// Seed the training and validation sets are generated from, so runs
+	// can share them.  0 draws them from the run's random numbers.
+	CaseSeed int64
+
+	// Goroutines used to evaluate forms.  0 uses GOMAXPROCS.
+	Workers int
+
+	// Operations forms are built from.  nil uses DefaultInstructionSet.
+	InstructionSet *InstructionSet `json:"-"`
+}
+
+// The configuration the package has always run with.
+func DefaultConfig() Config {
+	return Config{
+		Forms:             MAXFORMS,
+		StabilityDuration: STABILITYDURATION,
+		RaceTrials:        RACETRIALS,
+		CodeSize:          CODESIZE,
+		MemSize:           MEMSIZE,
+		IOSize:            IOSIZE,
+		MaxOps:            MAXOPS,
+		MutationRate:      MUTATIONRATE,
+		Crossover:         "one-point",
+		Selection:         BUCKETSELECTION,
+		TournamentSize:    TOURNAMENTSIZE,
+		RankPressure:      RANKPRESSURE,
+		TruncationPercent: TRUNCATIONPERCENT,
+		NoveltyK:          NOVELTYK,
+		NoveltyProbes:     NOVELTYPROBES,
+		NoveltyArchive:    NOVELTYARCHIVE,
+	}
+}
+
+// Check the configuration for values the Evolver can't run with.
+func (c *Config) Validate() error {
+	positive := []struct {
+		name  string
+		value int
+	}{
+		{"Forms", c.Forms},
+		{"StabilityDuration", c.StabilityDuration},
+		{"RaceTrials", c.RaceTrials},
+		{"CodeSize", c.CodeSize},
+		{"MemSize", c.MemSize},
+		{"IOSize", c.IOSize},
+		{"MaxOps", c.MaxOps},
+		{"MutationRate", c.MutationRate},
+	}
+	for _, p := range positive {
+		if p.value < 1 {
+			return fmt.Errorf("config: %s must be at least 1, got %d", p.name, p.value)
+		}
+	}
+
+	if c.CrossoverRate < 0 || c.CrossoverRate > 1 {
+		return fmt.Errorf("config: CrossoverRate must be between 0 and 1, got %g", c.CrossoverRate)
+	}
+	if c.CrossoverRate > 0 {
+		if _, err := LookupCrossover(c.Crossover); err != nil {
+			return fmt.Errorf("config: %v", err)
+		}
+	}
+
+	if _, err := NewSelection(c); err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	switch {
+	case c.Selection == "tournament" && c.TournamentSize < 1:
+		return fmt.Errorf("config: TournamentSize must be at least 1, got %d", c.TournamentSize)
+	case c.Selection == "rank" && (c.RankPressure < 1 || c.RankPressure > 2):
+		return fmt.Errorf("config: RankPressure must be between 1 and 2, got %g", c.RankPressure)
+	case c.Selection == "truncation" && (c.TruncationPercent <= 0 || c.TruncationPercent > 100):
+		return fmt.Errorf("config: TruncationPercent must be above 0 and at most 100, got %g", c.TruncationPercent)
+	}
+
+	if c.Elites < 0 || c.Elites >= c.Forms {
+		return fmt.Errorf("config: Elites must be between 0 and Forms-1, got %d", c.Elites)
+	}
+	if c.HallOfFame < 0 {
+		return fmt.Errorf("config: HallOfFame must not be negative, got %d", c.HallOfFame)
+	}
+
+	if c.Novelty < 0 || c.Novelty > 1 {
+		return fmt.Errorf("config: Novelty must be between 0 and 1, got %g", c.Novelty)
+	}
+	if c.Novelty > 0 {
+		switch {
+		case c.NoveltyK < 1:
+			return fmt.Errorf("config: NoveltyK must be at least 1, got %d", c.NoveltyK)
+		case c.NoveltyProbes < 1:
+			return fmt.Errorf("config: NoveltyProbes must be at least 1, got %d", c.NoveltyProbes)
+		case c.NoveltyArchive < 0:
+			return fmt.Errorf("config: NoveltyArchive must not be negative, got %d", c.NoveltyArchive)
+		}
+	}
+
+	if c.TrainingCases < 0 {
+		return fmt.Errorf("config: TrainingCases must not be negative, got %d", c.TrainingCases)
+	}
+	if c.ValidationCases < 0 {
+		return fmt.Errorf("config: ValidationCases must not be negative, got %d", c.ValidationCases)
+	}
+
+	if c.Workers < 0 {
+		return fmt.Errorf("config: Workers must not be negative, got %d", c.Workers)
+	}
+	if c.InstructionSet != nil && c.InstructionSet.Len() == 0 {
+		return fmt.Errorf("config: InstructionSet has no operations")
+	}
+
+	return nil
+}
+
+// The configured instruction set, or the default one.
+func (c *Config) instructionSet() *InstructionSet {
+	if c.InstructionSet == nil {
+		c.InstructionSet = DefaultInstructionSet()
+	}
+	return c.InstructionSet
+}
@@ -0,0 +1,44 @@
+package evo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	c := DefaultConfig()
+	require.NoError(t, c.Validate())
+
+	c.CodeSize = 0
+	assert.Error(t, c.Validate())
+
+	c = DefaultConfig()
+	c.Workers = -1
+	assert.Error(t, c.Validate())
+
+	_, err := NewEvolver(CopyProblem{}, c)
+	assert.Error(t, err)
+}
+
+func TestConfigCarriedToForms(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 30
+	c.CodeSize = 17
+	c.MemSize = 3
+	c.IOSize = 2
+	c.Seed = 5
+
+	e, err := NewEvolver(CopyProblem{}, c)
+	require.NoError(t, err)
+	assert.Equal(t, 30, len(e.forms))
+
+	e.runIteration()
+	e.mutateFormsBucketStrategy(nil)
+	for _, f := range e.forms {
+		assert.Equal(t, 17, len(f.instructions))
+		assert.Equal(t, 3, len(f.mem))
+		assert.Equal(t, 2, len(f.output))
+	}
+}
@@ -0,0 +1,129 @@
+package evo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// A Crossover recombines the programs of two parents into two children.
+// Parents may differ in length; children are between 1 and the first
+// parent's CodeSize instructions long and use the first parent's
+// configuration.  Children start with no statistics.
+type Crossover func(a, b Form, rng *rand.Rand) (Form, Form)
+
+// Crossover operators selectable by name with Config.Crossover.
+var crossovers = map[string]Crossover{
+	"one-point":  OnePointCrossover,
+	"two-point":  TwoPointCrossover,
+	"uniform":    UniformCrossover,
+	"homologous": HomologousCrossover,
+}
+
+// Names of the crossover operators, sorted.
+func CrossoverNames() []string {
+	var names []string
+	for name := range crossovers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The crossover operator registered under name.
+func LookupCrossover(name string) (Crossover, error) {
+	c, ok := crossovers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown crossover %q", name)
+	}
+	return c, nil
+}
+
+// Each parent is cut at its own random point and the tails are swapped, so
+// children can be longer or shorter than their parents.
+func OnePointCrossover(a, b Form, rng *rand.Rand) (Form, Form) {
+	ca := rng.Intn(len(a.instructions) + 1)
+	cb := rng.Intn(len(b.instructions) + 1)
+
+	c1 := join(a.instructions[:ca], b.instructions[cb:])
+	c2 := join(b.instructions[:cb], a.instructions[ca:])
+
+	return crossoverChild(a, c1), crossoverChild(a, c2)
+}
+
+// Each parent has a random segment chosen independently and the segments
+// are swapped.
+func TwoPointCrossover(a, b Form, rng *rand.Rand) (Form, Form) {
+	a1, a2 := segment(len(a.instructions), rng)
+	b1, b2 := segment(len(b.instructions), rng)
+
+	c1 := join(a.instructions[:a1], b.instructions[b1:b2], a.instructions[a2:])
+	c2 := join(b.instructions[:b1], a.instructions[a1:a2], b.instructions[b2:])
+
+	return crossoverChild(a, c1), crossoverChild(a, c2)
+}
+
+// Instructions at the same position are swapped with even odds.  Positions
+// past the end of the shorter parent come from the child's own parent.
+func UniformCrossover(a, b Form, rng *rand.Rand) (Form, Form) {
+	c1 := join(a.instructions)
+	c2 := join(b.instructions)
+
+	for i := 0; i < len(c1) && i < len(c2); i++ {
+		if rng.Intn(2) == 0 {
+			c1[i], c2[i] = c2[i], c1[i]
+		}
+	}
+
+	return crossoverChild(a, c1), crossoverChild(a, c2)
+}
+
+// Both parents are cut at the same two positions and the segments between
+// them are swapped, so instructions keep their position (and jump targets
+// keep their meaning) and children keep their parents' lengths.
+func HomologousCrossover(a, b Form, rng *rand.Rand) (Form, Form) {
+	shorter := len(a.instructions)
+	if len(b.instructions) < shorter {
+		shorter = len(b.instructions)
+	}
+	p1, p2 := segment(shorter, rng)
+
+	c1 := join(a.instructions[:p1], b.instructions[p1:p2], a.instructions[p2:])
+	c2 := join(b.instructions[:p1], a.instructions[p1:p2], b.instructions[p2:])
+
+	return crossoverChild(a, c1), crossoverChild(a, c2)
+}
+
+// Random [start, end) segment of a program of length n.
+func segment(n int, rng *rand.Rand) (int, int) {
+	p1 := rng.Intn(n + 1)
+	p2 := rng.Intn(n + 1)
+	if p1 > p2 {
+		p1, p2 = p2, p1
+	}
+	return p1, p2
+}
+
+// Copy of the concatenated instruction slices.
+func join(parts ...[]Instruction) []Instruction {
+	var out []Instruction
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+func crossoverChild(parent Form, instructions []Instruction) Form {
+	f := Form{cfg: parent.cfg}
+	f.init()
+
+	if len(instructions) > f.cfg.CodeSize {
+		instructions = instructions[:f.cfg.CodeSize]
+	}
+	if len(instructions) == 0 {
+		instructions = []Instruction{{}}
+	}
+	f.instructions = instructions
+
+	return f
+}
@@ -0,0 +1,136 @@
+package evo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Form of n setval instructions tagged with tag and their position.
+func taggedForm(c *Config, tag int, n int) Form {
+	f := NewNoopForm(c)
+	f.instructions = nil
+	for i := 0; i < n; i++ {
+		f.instructions = append(f.instructions, NewInstruction(SETVAL, tag, i))
+	}
+	return f
+}
+
+func TestCrossoverOperators(t *testing.T) {
+	c := testConfig()
+	a := taggedForm(c, 1, 10)
+	b := taggedForm(c, 2, 6)
+	rng := testRand()
+
+	for _, name := range CrossoverNames() {
+		cross, err := LookupCrossover(name)
+		require.NoError(t, err)
+
+		for trial := 0; trial < 50; trial++ {
+			c1, c2 := cross(a, b, rng)
+			for _, child := range []Form{c1, c2} {
+				assert.True(t, len(child.instructions) >= 1, name)
+				assert.True(t, len(child.instructions) <= c.CodeSize, name)
+				assert.Equal(t, 0, child.runCount, name)
+				assert.Equal(t, c.IOSize, len(child.output), name)
+			}
+
+			// Every child instruction comes from a parent, apart from the
+			// noop an empty child is given.
+			for _, ins := range append(c1.instructions, c2.instructions...) {
+				assert.True(t, ins.p1 == 1 || ins.p1 == 2 || ins == Instruction{}, name)
+			}
+		}
+	}
+
+	_, err := LookupCrossover("three-point")
+	assert.Error(t, err)
+}
+
+func TestHomologousCrossoverKeepsPositions(t *testing.T) {
+	c := testConfig()
+	a := taggedForm(c, 1, 10)
+	b := taggedForm(c, 2, 6)
+	rng := testRand()
+
+	for trial := 0; trial < 50; trial++ {
+		c1, c2 := HomologousCrossover(a, b, rng)
+		assert.Equal(t, 10, len(c1.instructions))
+		assert.Equal(t, 6, len(c2.instructions))
+		for i, ins := range append(c1.instructions, c2.instructions...) {
+			assert.Equal(t, i%10, ins.p2)
+		}
+	}
+}
+
+func TestUniformCrossoverMixes(t *testing.T) {
+	c := testConfig()
+	a := taggedForm(c, 1, 10)
+	b := taggedForm(c, 2, 10)
+
+	c1, _ := UniformCrossover(a, b, testRand())
+	var fromA, fromB int
+	for _, ins := range c1.instructions {
+		if ins.p1 == 1 {
+			fromA++
+		} else {
+			fromB++
+		}
+	}
+	assert.True(t, fromA > 0 && fromB > 0)
+}
+
+func TestEvolverCrossoverRate(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 100
+	c.Seed = 3
+	c.CrossoverRate = 0.5
+	c.Crossover = "two-point"
+
+	e, err := NewEvolver(CopyProblem{}, c)
+	require.NoError(t, err)
+	for i := range e.forms {
+		e.forms[i] = NewRandomForm(e.cfg, e.rng)
+	}
+	e.runIteration()
+	e.mutateFormsBucketStrategy(nil)
+	e.runIteration()
+	e.nextGeneration(TruncationSelection{Percent: 20}, nil)
+	assert.Equal(t, 100, len(e.forms))
+
+	c.Crossover = "bogus"
+	_, err = NewEvolver(CopyProblem{}, c)
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "bogus"))
+	}
+}
+
+func TestCrossoverChildrenKeepLength(t *testing.T) {
+	c := testConfig()
+	short := taggedForm(c, 1, 4)
+	assert.Equal(t, 4, len(NewChildForm(short, true, testRand()).instructions))
+
+	cfg := DefaultConfig()
+	cfg.Forms = 100
+	cfg.Seed = 1
+	cfg.CrossoverRate = 1
+	cfg.Crossover = "one-point"
+	e, err := NewEvolver(CopyProblem{}, cfg)
+	require.NoError(t, err)
+	for i := range e.forms {
+		e.forms[i] = taggedForm(e.cfg, 1+i%2, 2+i%8)
+	}
+	e.runIteration()
+	e.nextGeneration(TournamentSelection{Size: 2}, nil)
+	require.Equal(t, 100, len(e.forms))
+
+	// Children take the lengths crossover gives them rather than CodeSize.
+	lengths := map[int]bool{}
+	for _, f := range e.forms {
+		lengths[len(f.instructions)] = true
+		assert.True(t, len(f.instructions) <= cfg.CodeSize)
+	}
+	assert.True(t, len(lengths) > 3)
+}
@@ -0,0 +1,147 @@
+package evo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Reason a Curriculum's Run gives once its last stage has advanced.
+const REASONCURRICULUMDONE = "completed the curriculum"
+
+// A problem of a curriculum and when to move on from it.
+type Stage struct {
+	// Shown in reports; empty means "stage N", counting from 1.
+	Name string
+
+	Problem ProblemInterface
+
+	// When to advance to the next stage, e.g. SolvedAndStable(),
+	// TargetScore(-10) or MaxIterations(200) for a generation budget.  It
+	// sees the stage's own iterations, evaluations and elapsed time; nil
+	// means SolvedAndStable.
+	Until StopCondition
+}
+
+// The outcome of a stage.  Iterations, Evaluations and Elapsed count the
+// stage alone.
+type StageResult struct {
+	Name string
+	Result
+}
+
+// A Curriculum evolves one population through a sequence of problems, each
+// a stepping stone to the next: a population that can copy an input is
+// closer to one that adds two than a population of noop forms.  Each stage
+// starts from the final population of the stage before, and the run ends
+// once the last stage advances.
+type Curriculum struct {
+	evolver *Evolver
+	stages  []Stage
+
+	// Results of the stages run so far.
+	results []StageResult
+}
+
+// Create a curriculum of the stages in order, evolved with the given
+// configuration.
+func NewCurriculum(stages []Stage, c Config) (*Curriculum, error) {
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("curriculum: no stages")
+	}
+	stages = append([]Stage(nil), stages...)
+	for i := range stages {
+		if stages[i].Problem == nil {
+			return nil, fmt.Errorf("curriculum: stage %d has no problem", i+1)
+		}
+		if stages[i].Name == "" {
+			stages[i].Name = fmt.Sprintf("stage %d", i+1)
+		}
+		if stages[i].Until == nil {
+			stages[i].Until = SolvedAndStable()
+		}
+	}
+
+	e, err := NewEvolver(stages[0].Problem, c)
+	if err != nil {
+		return nil, err
+	}
+	return &Curriculum{evolver: &e, stages: stages}, nil
+}
+
+// The curriculum's settings, with defaults filled in.
+func (c *Curriculum) Config() Config {
+	return c.evolver.Config()
+}
+
+// The stages, with defaults filled in.
+func (c *Curriculum) Stages() []Stage {
+	return append([]Stage(nil), c.stages...)
+}
+
+// Results of the stages run so far, the last one unfinished if Run stopped
+// early.
+func (c *Curriculum) Results() []StageResult {
+	return append([]StageResult(nil), c.results...)
+}
+
+// Observers see the iterations of every stage, numbered on from one stage
+// to the next.
+func (c *Curriculum) AddObserver(o Observer) {
+	c.evolver.AddObserver(o)
+}
+
+// Set when Run stops early, before the last stage advances.  Conditions
+// see the whole run; unlike Evolver, there's no default.
+func (c *Curriculum) StopWhen(conds ...StopCondition) {
+	c.evolver.StopWhen(conds...)
+}
+
+// Evolve through the stages until the last one advances, a stop condition
+// triggers or ctx is done.  The result describes the whole run and its
+// final population; see Results for each stage.
+func (c *Curriculum) Run(ctx context.Context) (Result, error) {
+	start := time.Now()
+	e := c.evolver
+	c.results = nil
+
+	for i, stage := range c.stages {
+		if i > 0 {
+			e.setProblem(stage.Problem)
+		}
+		stageStart := time.Now()
+		from := e.runState(0)
+
+		for {
+			e.step()
+			s := e.runState(time.Since(start))
+			local := s
+			local.Iteration -= from.Iteration
+			local.Evaluations -= from.Evaluations
+			local.Elapsed = time.Since(stageStart)
+
+			if err := ctx.Err(); err != nil {
+				c.finish(stage, local, REASONCANCELLED)
+				return e.result(s, REASONCANCELLED), err
+			}
+			if e.stop != nil {
+				if reason := e.stop.ShouldStop(s); reason != "" {
+					c.finish(stage, local, reason)
+					return e.result(s, reason), nil
+				}
+			}
+			if reason := stage.Until.ShouldStop(local); reason != "" {
+				c.finish(stage, local, reason)
+				break
+			}
+
+			e.advance()
+		}
+	}
+	return e.result(e.runState(time.Since(start)), REASONCURRICULUMDONE), nil
+}
+
+// Record the result of a stage.
+func (c *Curriculum) finish(stage Stage, s RunState, reason string) {
+	c.results = append(c.results, StageResult{stage.Name, c.evolver.result(s, reason)})
+}
@@ -0,0 +1,82 @@
+package evo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurriculum(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 200
+	c.StabilityDuration = 5
+	c.Seed = 1
+	cu, err := NewCurriculum([]Stage{
+		{Name: "output1", Problem: Output1Problem{}, Until: AnyOf(SolvedAndStable(), MaxIterations(200))},
+		{Problem: CopyProblem{}, Until: MaxIterations(3)},
+		{Problem: AdditionProblem{}, Until: TargetScore(0)},
+	}, c)
+	require.NoError(t, err)
+	assert.Equal(t, "stage 2", cu.Stages()[1].Name)
+
+	rec := &recordingObserver{}
+	cu.AddObserver(rec)
+	cu.StopWhen(MaxIterations(250))
+	res, err := cu.Run(context.Background())
+	require.NoError(t, err)
+
+	stages := cu.Results()
+	require.Equal(t, 3, len(stages))
+	assert.Equal(t, "output1", stages[0].Name)
+	assert.True(t, stages[0].Solved && stages[0].Stable, stages[0].Reason)
+
+	// The budgeted stage counts its own iterations, and starts unsolved
+	// rather than carrying the first stage's success over.
+	assert.Equal(t, 3, stages[1].Iterations)
+	assert.Equal(t, int64(3*200*c.RaceTrials), stages[1].Evaluations)
+	assert.False(t, rec.iterations[stages[0].Iterations].Solved)
+
+	// The run as a whole stops at its own limit.
+	assert.Equal(t, "stage 3", stages[2].Name)
+	assert.Equal(t, stages[0].Iterations+stages[1].Iterations+stages[2].Iterations, res.Iterations)
+	assert.Equal(t, len(rec.iterations), res.Iterations)
+	if res.Reason != REASONCURRICULUMDONE {
+		assert.Equal(t, 250, res.Iterations)
+		assert.Equal(t, res.Reason, stages[2].Reason)
+	}
+}
+
+func TestCurriculumCarriesPopulation(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 20
+	c.Seed = 1
+	cu, err := NewCurriculum([]Stage{
+		{Problem: Output1Problem{}, Until: MaxIterations(1)},
+		{Problem: CopyProblem{}, Until: MaxIterations(1)},
+	}, c)
+	require.NoError(t, err)
+
+	// A population that has solved copy goes on solving it.
+	for i := range cu.evolver.forms {
+		cu.evolver.forms[i] = NewCopyForm(cu.evolver.cfg)
+	}
+	res, err := cu.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, REASONCURRICULUMDONE, res.Reason)
+	assert.Equal(t, 2, res.Iterations)
+	assert.False(t, cu.Results()[0].Solved)
+	assert.True(t, cu.Results()[1].Solved)
+}
+
+func TestNewCurriculumErrors(t *testing.T) {
+	_, err := NewCurriculum(nil, DefaultConfig())
+	assert.Error(t, err)
+	_, err = NewCurriculum([]Stage{{Name: "empty"}}, DefaultConfig())
+	assert.Error(t, err)
+	c := DefaultConfig()
+	c.Forms = 0
+	_, err = NewCurriculum([]Stage{{Problem: CopyProblem{}}}, c)
+	assert.Error(t, err)
+}
@@ -0,0 +1,255 @@
+package evo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Settings for reading a DatasetProblem.
+type DatasetConfig struct {
+	// Columns holding the inputs and the targets, by header name or 0-based
+	// index.  No Inputs means every column that isn't a target; no Targets
+	// means the last column.
+	Inputs  []string
+	Targets []string
+
+	// Is the first row a header naming the columns?
+	Header bool
+
+	// Values are multiplied by Scale and rounded, as forms compute with
+	// integers: 100 keeps two decimal places.  0 means 1.
+	Scale float64
+
+	// Fraction (0 to 1) of the rows held out as the test set.
+	TestFraction float64
+
+	// Rows are shuffled with Seed before the test set is split off; 0 keeps
+	// the file's order, so the last rows are the test set.
+	Seed int64
+}
+
+// A problem whose cases are the rows of a table, for fitting programs to
+// real data.  Each trial is a training row drawn at random, so an iteration
+// scores forms on a fresh mini-batch of Config.RaceTrials rows; setting
+// Config.TrainingCases fixes the batch instead.  Test rows are never drawn;
+// use them as validation cases (see Evolver.SetCases) to see how well a
+// program generalizes.
+type DatasetProblem struct {
+	Problem
+
+	// Names of the input and target columns.
+	InputColumns  []string
+	TargetColumns []string
+
+	train []Case
+	test  []Case
+
+	// Target of each distinct training input.  Rows with the same inputs
+	// but different targets share their mean target.
+	answers map[string][]int
+}
+
+// Read a dataset from a CSV file.
+func DatasetProblemFromFile(path string, dc DatasetConfig) (*DatasetProblem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	d, err := NewDatasetProblem(file, dc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return d, nil
+}
+
+// Read a dataset from CSV.
+func NewDatasetProblem(r io.Reader, dc DatasetConfig) (*DatasetProblem, error) {
+	if dc.Scale == 0 {
+		dc.Scale = 1
+	}
+	if dc.Scale < 0 {
+		return nil, fmt.Errorf("dataset: Scale must be positive, got %g", dc.Scale)
+	}
+	if dc.TestFraction < 0 || dc.TestFraction >= 1 {
+		return nil, fmt.Errorf("dataset: TestFraction must be at least 0 and below 1, got %g", dc.TestFraction)
+	}
+
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("dataset: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("dataset: no rows")
+	}
+
+	names := make([]string, len(records[0]))
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+	}
+	first := 1
+	if dc.Header {
+		for i, name := range records[0] {
+			names[i] = strings.TrimSpace(name)
+		}
+		records = records[1:]
+		first = 2
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("dataset: no rows")
+	}
+
+	targets, err := datasetColumns(dc.Targets, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		targets = []int{len(names) - 1}
+	}
+	inputs, err := datasetColumns(dc.Inputs, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		for i := range names {
+			if !containsInt(targets, i) {
+				inputs = append(inputs, i)
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("dataset: no input columns")
+	}
+
+	d := &DatasetProblem{answers: map[string][]int{}}
+	for _, i := range inputs {
+		d.InputColumns = append(d.InputColumns, names[i])
+	}
+	for _, i := range targets {
+		d.TargetColumns = append(d.TargetColumns, names[i])
+	}
+
+	rows := make([]Case, len(records))
+	for n, record := range records {
+		value := func(col int) (int, error) {
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[col]), 64)
+			if err != nil {
+				return 0, fmt.Errorf("dataset: line %d, column %s: %q isn't a number", first+n, names[col], record[col])
+			}
+			return int(math.Round(v * dc.Scale)), nil
+		}
+		for _, col := range inputs {
+			v, err := value(col)
+			if err != nil {
+				return nil, err
+			}
+			rows[n].Input = append(rows[n].Input, v)
+		}
+		for _, col := range targets {
+			v, err := value(col)
+			if err != nil {
+				return nil, err
+			}
+			rows[n].Answer = append(rows[n].Answer, v)
+		}
+	}
+
+	if dc.Seed != 0 {
+		rng := rand.New(newSource(dc.Seed))
+		rng.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+	}
+	split := len(rows) - int(math.Round(float64(len(rows))*dc.TestFraction))
+	if split < 1 {
+		return nil, fmt.Errorf("dataset: no training rows left after the test split")
+	}
+	d.train, d.test = rows[:split], rows[split:]
+
+	// Mean target of each distinct input.
+	sums := map[string][]int{}
+	counts := map[string]int{}
+	for _, row := range d.train {
+		key := fmt.Sprint(row.Input)
+		if sums[key] == nil {
+			sums[key] = make([]int, len(row.Answer))
+		}
+		for k, v := range row.Answer {
+			sums[key][k] += v
+		}
+		counts[key]++
+	}
+	for key, sum := range sums {
+		answer := make([]int, len(sum))
+		for k, v := range sum {
+			answer[k] = int(math.Round(float64(v) / float64(counts[key])))
+		}
+		d.answers[key] = answer
+	}
+
+	return d, nil
+}
+
+// Column indexes of names, each a header name or a 0-based index.
+func datasetColumns(columns []string, names []string) ([]int, error) {
+	var indexes []int
+	for _, col := range columns {
+		col = strings.TrimSpace(col)
+		found := -1
+		for i, name := range names {
+			if name == col {
+				found = i
+				break
+			}
+		}
+		if i, err := strconv.Atoi(col); found < 0 && err == nil && i >= 0 && i < len(names) {
+			found = i
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("dataset: no column %q", col)
+		}
+		indexes = append(indexes, found)
+	}
+	return indexes, nil
+}
+
+func containsInt(values []int, v int) bool {
+	for _, w := range values {
+		if w == v {
+			return true
+		}
+	}
+	return false
+}
+
+// The training rows.
+func (d *DatasetProblem) Train() []Case {
+	return d.train
+}
+
+// The held out test rows.
+func (d *DatasetProblem) Test() []Case {
+	return d.test
+}
+
+// A training row's inputs.
+func (d *DatasetProblem) GenerateInputs(rng *rand.Rand) []int {
+	return append([]int(nil), d.train[rng.Intn(len(d.train))].Input...)
+}
+
+// The target of a training row with these inputs.  Inputs not in the
+// training rows, which GenerateInputs never draws, answer 0 on every target
+// rather than nil, which would score as perfect.
+func (d *DatasetProblem) Answer(input []int) []int {
+	if answer, ok := d.answers[fmt.Sprint(input)]; ok {
+		return answer
+	}
+	return make([]int, len(d.TargetColumns))
+}
@@ -0,0 +1,138 @@
+package evo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDatasetProblem(t *testing.T) {
+	csv := "x, noise, y\n1.25, 9, 2.5\n-0.5, 9, -1\n1.25, 9, 3.0\n"
+	d, err := NewDatasetProblem(strings.NewReader(csv), DatasetConfig{
+		Inputs:  []string{"x"},
+		Targets: []string{"y"},
+		Header:  true,
+		Scale:   100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, d.InputColumns)
+	assert.Equal(t, []string{"y"}, d.TargetColumns)
+	require.Equal(t, 3, len(d.Train()))
+	assert.Equal(t, Case{Input: []int{125}, Answer: []int{250}}, d.Train()[0])
+	assert.Equal(t, 0, len(d.Test()))
+
+	// Rows with equal inputs answer their mean target.
+	assert.Equal(t, []int{275}, d.Answer([]int{125}))
+	assert.Equal(t, []int{-100}, d.Answer([]int{-50}))
+
+	// An input outside the dataset answers zeros, which a program's output
+	// is scored against like any other answer.
+	assert.Equal(t, []int{0}, d.Answer([]int{7}))
+	assert.Equal(t, -3.0, d.Score(d.Answer([]int{7}), []int{3}))
+
+	rng := testRand()
+	for i := 0; i < 20; i++ {
+		input := d.GenerateInputs(rng)
+		assert.NotNil(t, d.Answer(input), "%v", input)
+	}
+}
+
+func TestDatasetDefaultColumns(t *testing.T) {
+	d, err := NewDatasetProblem(strings.NewReader("1,2,3\n4,5,6\n"), DatasetConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, d.InputColumns)
+	assert.Equal(t, []string{"2"}, d.TargetColumns)
+	assert.Equal(t, Case{Input: []int{4, 5}, Answer: []int{6}}, d.Train()[1])
+
+	d, err = NewDatasetProblem(strings.NewReader("1,2,3\n4,5,6\n"), DatasetConfig{Targets: []string{"0", "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, Case{Input: []int{6}, Answer: []int{4, 5}}, d.Train()[1])
+}
+
+func TestDatasetSplit(t *testing.T) {
+	var csv strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&csv, "%d,%d\n", i, 2*i)
+	}
+
+	d, err := NewDatasetProblem(strings.NewReader(csv.String()), DatasetConfig{TestFraction: 0.2})
+	require.NoError(t, err)
+	require.Equal(t, 80, len(d.Train()))
+	require.Equal(t, 20, len(d.Test()))
+	assert.Equal(t, []int{80}, d.Test()[0].Input)
+
+	// Shuffled before splitting; the same seed splits the same way.
+	s, err := NewDatasetProblem(strings.NewReader(csv.String()), DatasetConfig{TestFraction: 0.2, Seed: 3})
+	require.NoError(t, err)
+	assert.NotEqual(t, d.Test(), s.Test())
+	again, err := NewDatasetProblem(strings.NewReader(csv.String()), DatasetConfig{TestFraction: 0.2, Seed: 3})
+	require.NoError(t, err)
+	assert.Equal(t, s.Test(), again.Test())
+
+	// Test rows are never drawn.
+	rng := testRand()
+	for i := 0; i < 200; i++ {
+		input := s.GenerateInputs(rng)
+		for _, c := range s.Test() {
+			require.NotEqual(t, c.Input, input)
+		}
+	}
+}
+
+func TestDatasetErrors(t *testing.T) {
+	bad := []struct {
+		csv string
+		dc  DatasetConfig
+	}{
+		{"", DatasetConfig{}},
+		{"x,y\n", DatasetConfig{Header: true}},
+		{"1,2\n3,x\n", DatasetConfig{}},
+		{"1,2\n3\n", DatasetConfig{}},
+		{"x,y\n1,2\n", DatasetConfig{Header: true, Targets: []string{"z"}}},
+		{"1,2\n", DatasetConfig{Inputs: []string{"5"}}},
+		{"1\n", DatasetConfig{}},
+		{"1,2\n", DatasetConfig{TestFraction: 1}},
+		{"1,2\n", DatasetConfig{TestFraction: 0.9}},
+		{"1,2\n", DatasetConfig{Scale: -1}},
+	}
+	for i, b := range bad {
+		_, err := NewDatasetProblem(strings.NewReader(b.csv), b.dc)
+		assert.Error(t, err, "case %d", i)
+	}
+}
+
+func TestDatasetProblemFromFile(t *testing.T) {
+	var csv strings.Builder
+	fmt.Fprintln(&csv, "a,b,sum")
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&csv, "%d,%d,%d\n", i%7, i%5, i%7+i%5)
+	}
+	path := filepath.Join(t.TempDir(), "sum.csv")
+	require.NoError(t, os.WriteFile(path, []byte(csv.String()), 0644))
+
+	d, err := DatasetProblemFromFile(path, DatasetConfig{Header: true, TestFraction: 0.25, Seed: 1})
+	require.NoError(t, err)
+
+	c := DefaultConfig()
+	c.Forms = 2000
+	c.StabilityDuration = 5
+	c.Seed = 1
+	e, err := NewEvolver(d, c)
+	require.NoError(t, err)
+	e.SetCases(nil, d.Test())
+	e.StopWhen(SolvedAndStable(), MaxIterations(300))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, res.Solved, res.Reason)
+	assert.Equal(t, 0.0, res.ValidationScore)
+
+	_, err = DatasetProblemFromFile(filepath.Join(t.TempDir(), "missing.csv"), DatasetConfig{})
+	assert.Error(t, err)
+}
@@ -2,8 +2,14 @@ package evo
 
 import (
 	"math"
+	"context"
+	"math/rand"
 	"fmt"
+	"os"
+	"runtime"
 	"sort"
+	"sync"
+	"time"
 )
 
 // An Evolver has a set of (!life) forms and a problem/scorer that it uses to
@@ -13,10 +19,18 @@ type Evolver struct {
 
 	problem ProblemInterface
 
+	// Parameters of the run, shared with every form.
+	cfg *Config
+
+	// Every random decision of the run is drawn from rng.  Its source is kept
+	// so the state can be checkpointed.
+	rng       *rand.Rand
+	rngSource *source
+
 	// Is the problem solved (may be inefficient).
 	solved bool
 
-	// Has the solved problem's score not improved in STABILITYDURATION iterations.
+	// Has the solved problem's score not improved in StabilityDuration iterations.
 	solvedNStable bool
 
 	// Count of times the same top score has been produced.
@@ -28,67 +42,202 @@ type Evolver struct {
 	// Highest ever topScore
 	topScore float64
 
+	// Lowest cost seen at topScore
+	topCost float64
+
+	// Index of the best form of the most recent evaluation.
+	best int
+
 	// Best ever (lowest) cost
 	lastTopCost float64
+
+	// Number of completed iterations.
+	iteration int
+
+	// Directory periodic checkpoints are written to; empty for none.
+	checkpointDir string
+
+	// Write a checkpoint every this many iterations.
+	checkpointEvery int
+
+	// When to stop Run; nil for SolvedAndStable.
+	stop StopCondition
+
+	// Forms run so far.
+	evaluations int64
+
+	// Iteration count when topScore or topCost last improved.
+	improvedAt int
+
+	// Notified of progress by RunAndReport.
+	observers []Observer
+
+	// Best programs ever seen; nil unless Config.HallOfFame is set.
+	hallOfFame *HallOfFame
+
+	// Novelty search: the probe inputs behaviors are measured on, past
+	// novel behaviors, and each form's novelty in the latest evaluation.
+	probes         [][]int
+	noveltyArchive [][]float64
+	novelty        []float64
+
+	// Fixed training and validation cases; nil unless set or configured.
+	training   []Case
+	validation []Case
+
+	// Mean score of the best form of the most recent evaluation on the
+	// validation cases.
+	validationScore float64
 }
 
-const MAXFORMS = 10000
-const STABILITYDURATION = 500
-const RACETRIALS = 20
+// Create an evolver for the problem.  The configuration is copied; see
+// DefaultConfig.
+func NewEvolver(p ProblemInterface, c Config) (Evolver, error) {
+	if err := c.Validate(); err != nil {
+		return Evolver{}, err
+	}
 
-func NewEvolver(p ProblemInterface) Evolver {
 	e := Evolver{}
+	e.cfg = &c
+	e.cfg.instructionSet()
+	if e.cfg.Workers == 0 {
+		e.cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	if e.cfg.Seed == 0 {
+		e.cfg.Seed = time.Now().UnixNano()
+	}
+	e.Seed(e.cfg.Seed)
 	e.solved = false
 	e.solvedNStable = false
 	e.topScore = -math.MaxFloat64
 	e.forms = []Form{}
 
-	for _ = range [MAXFORMS] struct{}{} {
+	for i := 0; i < e.cfg.Forms; i++ {
 		// Create random value or noop (all zero) initial forms.
-		// e.forms = append(e.forms, NewRandomForm())
-		e.forms = append(e.forms, NewNoopForm())
-		// e.forms = append(e.forms, NewCopyForm())
+		// e.forms = append(e.forms, NewRandomForm(e.cfg, e.rng))
+		e.forms = append(e.forms, NewNoopForm(e.cfg))
+		// e.forms = append(e.forms, NewCopyForm(e.cfg))
 	}
 
 	e.problem = p
+	if e.cfg.HallOfFame > 0 {
+		e.hallOfFame = NewHallOfFame(e.cfg.HallOfFame)
+	}
+
+	return e, nil
+}
+
+// Reseed the evolver's random number generator.  Two evolvers with the same
+// problem and seed make identical decisions, so a run can be reproduced from
+// its seed.  Call before running.
+func (e *Evolver) Seed(seed int64) {
+	e.cfg.Seed = seed
+	e.rngSource = newSource(seed)
+	e.rng = rand.New(e.rngSource)
+}
+
+// The evolver's configuration, with defaults filled in.
+func (e *Evolver) Config() Config {
+	return *e.cfg
+}
 
-	return e
+// Has a solution (score 0.0) been found?
+func (e *Evolver) Solved() bool {
+	return e.solved
 }
 
-// Mutate forms by allocating an all new set of forms based on
-// the top N% best performing forms.
-func (e *Evolver) mutateForms() {
-	// Take the top N% of forms and duplicate them into new slots.
+// Number of iterations run so far.
+func (e *Evolver) Iteration() int {
+	return e.iteration
+}
 
-	var topPct float32 = 20
+// Copy of the best form of the most recent evaluation.
+func (e *Evolver) Best() Form {
+	return e.forms[e.best].Clone()
+}
 
-	topNFloat := float32(len(e.forms)) * float32(topPct)/100.0
-	topN := int(topNFloat)
-	newForms := []Form{}
-	newPerTop := int(float32(MAXFORMS)/float32(topN))
+// The best distinct programs seen so far, or nil if Config.HallOfFame is 0.
+func (e *Evolver) HallOfFame() *HallOfFame {
+	return e.hallOfFame
+}
 
-	for i:=0; i< topN; i++ {
-		// Copy one intact.
-		nf := NewChildForm(e.forms[i], false)
-		newForms = append(newForms, nf)
+// Add an observer to be notified of the run's progress.
+func (e *Evolver) AddObserver(o Observer) {
+	e.observers = append(e.observers, o)
+}
 
-		// And the remainder as mutations
-		for j:=1; j < newPerTop; j++ {
-			nf := NewChildForm(e.forms[i], true)
-			newForms = append(newForms, nf)
+// Copies of the Config.Elites best forms, ready to be evaluated again.
+func (e *Evolver) elites() []Form {
+	if e.cfg.Elites == 0 {
+		return nil
+	}
+
+	order := rankOrder(ByAvgScore(e.forms))
+	elites := make([]Form, e.cfg.Elites)
+	for i := range elites {
+		elites[i] = e.forms[order[i]].Clone()
+		elites[i].resetStats()
+	}
+	return elites
+}
+
+// Replace the forms with children of parents chosen by sel, followed by
+// the elites.  Crossover mates are chosen by sel too.  Survivors of a
+// SurvivorSelection fill the first slots.
+func (e *Evolver) nextGeneration(sel Selection, elites []Form) {
+	pop := e.population()
+	if ps, ok := sel.(preparedSelection); ok {
+		sel = ps.prepare(pop)
+	}
+
+	var newForms []Form
+	if ss, ok := sel.(SurvivorSelection); ok {
+		for _, i := range ss.Survivors(pop) {
+			if len(newForms) == len(e.forms)-len(elites) {
+				break
+			}
+			f := e.forms[i].Clone()
+			f.resetStats()
+			newForms = append(newForms, f)
 		}
 	}
 
-	e.forms = newForms
+	n := len(e.forms) - len(newForms) - len(elites)
+	parents := sel.Select(pop, n, e.rng)
+
+	// Mates are drawn together as selections can be costly to set up.
+	var mates []int
+	if e.cfg.CrossoverRate > 0 {
+		mates = sel.Select(pop, n, e.rng)
+	}
+
+	// A crossover's second child takes the next slot in place of that
+	// slot's own parent.
+	for k := 0; k < n; {
+		mate := func() Form { return e.forms[mates[k]] }
+		for _, child := range e.breed(e.forms[parents[k]], mate) {
+			if k < n {
+				newForms = append(newForms, child)
+				k++
+			}
+		}
+	}
+
+	e.forms = append(newForms, elites...)
 }
 
 
 // Scan over buckets of forms and mutate the best into the other slots of
-// that bucket.  Vary the bucket size so as to allow mixing between buckets.
-func (e *Evolver) mutateFormsBucketStrategy() {
-	var buckets int = rng.Intn(2) + 10 // Between 10 and 12 buckets.
+// that bucket, so each bucket's next generation descends from its winner.
+// Vary the bucket size so as to allow mixing between buckets.  Slots past
+// the last whole bucket are carried over unchanged, to be scored afresh.
+// The elites replace children from the last slots back, never a bucket's
+// winner.
+func (e *Evolver) mutateFormsBucketStrategy(elites []Form) {
+	var buckets int = e.rng.Intn(2) + 10 // Between 10 and 12 buckets.
 
 	var bucketLength int = len(e.forms) / buckets
+	pop := e.population()
 
 	for i:=0; i < buckets; i++ {
 		topInBucket := i*bucketLength
@@ -96,41 +245,129 @@ func (e *Evolver) mutateFormsBucketStrategy() {
 			// Find the best in the bucket.
 
 			// Using the Less() component of the sorter.
-			if (ByAvgScore(e.forms).Less(i*bucketLength+j, topInBucket)) {
+			if (pop.Less(i*bucketLength+j, topInBucket)) {
 				topInBucket = i*bucketLength + j
 			}
 		}
 		// Move the best one to the first position (overwrite is fine).
-		e.forms[i*bucketLength] = e.forms[topInBucket]
-		fmt.Println("Best score in bucket", i, " : ", e.forms[i*bucketLength].AvgScore(), " cost : ", e.forms[i*bucketLength].AvgCost())
+		e.forms[i*bucketLength] = e.forms[topInBucket].Clone()
 
 		e.forms[i*bucketLength].resetStats()
+	}
+
+	for i:=buckets*bucketLength; i < len(e.forms); i++ {
+		e.forms[i].resetStats()
+	}
 
+	// Crossover mates are the winners of other buckets.
+	mate := func() Form {
+		return e.forms[e.rng.Intn(buckets)*bucketLength]
+	}
+
+	for i:=0; i < buckets; i++ {
 		// Mutate the first position one over the remainder slots in the bucket.
-		for j:=1; j < bucketLength; j++ {
-			e.forms[i*bucketLength+j] = NewChildForm(e.forms[i*bucketLength+j], true)
+		for j:=1; j < bucketLength; {
+			for _, child := range e.breed(e.forms[i*bucketLength], mate) {
+				if j < bucketLength {
+					e.forms[i*bucketLength+j] = child
+					j++
+				}
+			}
 		}
+	}
 
+	var slots []int
+	for slot := len(e.forms) - 1; slot >= 0 && len(slots) < len(elites); slot-- {
+		if bucketLength == 0 || slot%bucketLength != 0 || slot >= buckets*bucketLength {
+			slots = append(slots, slot)
+		}
+	}
+	for k, slot := range slots {
+		e.forms[slot] = elites[len(slots)-1-k]
 	}
 }
 
+// A mutated child of parent.  At the configured crossover rate the parent
+// is first recombined with a mate instead, giving both mutated children of
+// the crossover.
+func (e *Evolver) breed(parent Form, mate func() Form) []Form {
+	if e.cfg.CrossoverRate > 0 && e.rng.Float64() < e.cfg.CrossoverRate {
+		c1, c2 := crossovers[e.cfg.Crossover](parent, mate(), e.rng)
+		return []Form{NewChildForm(c1, true, e.rng), NewChildForm(c2, true, e.rng)}
+	}
+	return []Form{NewChildForm(parent, true, e.rng)}
+}
 
+func (e *Evolver) runIteration() {
+	e.drawCases()
+
+	// Draw every trial's input up front so the random sequence doesn't
+	// depend on how evaluation is scheduled.
+	inputs := make([][]int, e.trials())
+	answers := make([][]int, e.trials())
+	for t := range inputs {
+		if len(e.training) > 0 {
+			inputs[t], answers[t] = e.training[t].Input, e.training[t].Answer
+			continue
+		}
+		inputs[t] = e.problem.GenerateInputs(e.rng)
+		answers[t] = e.problem.Answer(inputs[t])
+	}
 
+	e.shard(func(lo, hi int) {
+		e.scoreForms(e.forms[lo:hi], inputs, answers)
+	})
 
+	if e.cfg.Novelty > 0 {
+		e.updateNovelty()
+	}
+}
 
-func (e *Evolver) runIteration() {
-	// TODO: Is there a cleaner way of doing this loop without a named variable?
-	for _ = range [RACETRIALS] struct{}{} {
-		problemInput := e.problem.GenerateInputs()
-		problemAnswer := e.problem.Answer(problemInput)
-		for i := 0; i < len(e.forms); i++ {
-			e.forms[i].runCode(&problemInput)
-			e.forms[i].runCount++
-			runScore := e.problem.Score(problemAnswer, e.forms[i].output)
-			e.forms[i].scoreSum += runScore
+// Call fn on contiguous shards [lo, hi) of the forms, one per worker
+// goroutine.  Forms don't share mutable state so no locking is needed.
+func (e *Evolver) shard(fn func(lo, hi int)) {
+	e.shardN(len(e.forms), fn)
+}
+
+// Call fn on contiguous shards [lo, hi) of 0..n, one per worker goroutine.
+func (e *Evolver) shardN(n int, fn func(lo, hi int)) {
+	workers := e.cfg.Workers
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+
+	var wg sync.WaitGroup
+	size := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += size {
+		hi := lo + size
+		if hi > n {
+			hi = n
 		}
+
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, hi)
 	}
+	wg.Wait()
+}
 
+// Run each form against every trial input and accumulate its score.
+func (e *Evolver) scoreForms(forms []Form, inputs [][]int, answers [][]int) {
+	for i := range forms {
+		for t := range inputs {
+			forms[i].runCode(&inputs[t])
+			forms[i].runCount++
+			runScore := e.problem.Score(answers[t], forms[i].output)
+			forms[i].scoreSum += runScore
+			forms[i].caseScores = append(forms[i].caseScores, runScore)
+		}
+	}
 }
 
 func (e *Evolver) sortFormsByAvgScore() {
@@ -139,15 +376,26 @@ func (e *Evolver) sortFormsByAvgScore() {
 }
 
 func (e *Evolver) doBookKeeping() {
-	// Relies on the forms being reverse sorted by avgscore
-	runTopScore := e.forms[0].AvgScore()
+	e.best = 0
+	for i := 1; i < len(e.forms); i++ {
+		if ByAvgScore(e.forms).Less(i, e.best) {
+			e.best = i
+		}
+	}
+
+	runTopScore := e.forms[e.best].AvgScore()
+	runTopCost := e.forms[e.best].AvgCost()
 	e.lastTopScore = runTopScore
-	if (runTopScore == 0.0) {
+
+	// A solution must also solve the validation cases.
+	if len(e.validation) > 0 {
+		e.validationScore = e.validate(e.forms[e.best])
+	}
+	if (runTopScore == 0.0 && (len(e.validation) == 0 || e.validationScore == 0.0)) {
 		e.solved = true
-		runTopCost := e.forms[0].AvgCost()
 		if e.lastTopCost == runTopCost {
 			e.sameSolvedCostCount++
-			if e.sameSolvedCostCount >= STABILITYDURATION {
+			if e.sameSolvedCostCount >= e.cfg.StabilityDuration {
 				e.solvedNStable = true
 			}
 		} else {
@@ -157,34 +405,118 @@ func (e *Evolver) doBookKeeping() {
 		e.lastTopCost = runTopCost
 	}
 
-	e.topScore = math.Min(e.topScore, runTopScore)
+	if runTopScore > e.topScore || (runTopScore == e.topScore && runTopCost < e.topCost) {
+		e.topScore = runTopScore
+		e.topCost = runTopCost
+	}
 }
 
-// Run the evolution until complete (or FOREVER) and report status via stdout.
+// Run the evolution until complete (or FOREVER) and report status via stdout
+// in addition to any added observers.
 func (e *Evolver) RunAndReport() {
-	for i:=0 ; ; i++ {
+	observers := e.observers
+	e.observers = append([]Observer{NewStdoutObserver(os.Stdout)}, observers...)
+	defer func() { e.observers = observers }()
 
-		e.runIteration()
-		// e.sortFormsByAvgScore()
-		e.doBookKeeping()
+	res, _ := e.Run(context.Background())
+	fmt.Println("Stopped:", res.Reason)
+}
 
-		if (i % 10 == 0) {
-			fmt.Println("Best form:")
-			e.forms[0].Print()
+// Evaluate the forms and update the bookkeeping, notifying observers.
+func (e *Evolver) step() {
+	i := e.iteration
+	wasSolved, wasStable := e.solved, e.solvedNStable
+	prevScore, prevCost := e.topScore, e.topCost
+
+	e.runIteration()
+	// e.sortFormsByAvgScore()
+	e.doBookKeeping()
+	if e.hallOfFame != nil {
+		e.hallOfFame.addAll(e.forms, i)
+	}
+	e.iteration++
+	e.evaluations += int64(len(e.forms) * e.trials())
+
+	best := e.forms[e.best]
+	for _, o := range e.observers {
+		o.OnIteration(IterationEvent{
+			Iteration:       i,
+			BestScore:       best.AvgScore(),
+			BestCost:        best.AvgCost(),
+			Solved:          e.solved,
+			StableFor:       e.sameSolvedCostCount,
+			Population:      e.populationStats(),
+			Validated:       len(e.validation) > 0,
+			ValidationScore: e.validationScore,
+			Best:            best,
+		})
+	}
 
-			if e.solved {
-				fmt.Println("--Solved--  Stable for", e.sameSolvedCostCount, "iterations")
-			}
+	if e.topScore != prevScore || e.topCost != prevCost {
+		e.improvedAt = e.iteration
+		for _, o := range e.observers {
+			o.OnNewBest(NewBestEvent{
+				Iteration:     i,
+				Score:         e.topScore,
+				Cost:          e.topCost,
+				PreviousScore: prevScore,
+				PreviousCost:  prevCost,
+				Best:          best,
+			})
 		}
+	}
 
-		fmt.Println("Iteration ", i, " complete.  runTopScore : ", e.forms[0].AvgScore(), "cost:", e.forms[0].AvgCost())
+	if e.solved && !wasSolved {
+		for _, o := range e.observers {
+			o.OnSolved(SolvedEvent{Iteration: i, Cost: best.AvgCost(), Best: best})
+		}
+	}
 
-		if e.solvedNStable {
-			fmt.Println("Stable solution!")
-			break
+	if e.solvedNStable && !wasStable {
+		for _, o := range e.observers {
+			o.OnStable(StableEvent{Iteration: i, Cost: e.lastTopCost, StableFor: e.sameSolvedCostCount, Best: best})
 		}
+	}
+}
 
-		e.mutateFormsBucketStrategy()
-		// e.mutateForms()
+// Switch to problem p, keeping the population.  Progress towards the old
+// problem is forgotten: forms' scores are cleared, the run is unsolved
+// again, and cases, novelty probes and the hall of fame are drawn afresh.
+// Iterations and evaluations go on counting.
+func (e *Evolver) setProblem(p ProblemInterface) {
+	e.problem = p
+	for i := range e.forms {
+		e.forms[i].resetStats()
 	}
-}
\ No newline at end of file
+	e.solved = false
+	e.solvedNStable = false
+	e.sameSolvedCostCount = 0
+	e.topScore = -math.MaxFloat64
+	e.topCost = 0
+	e.lastTopCost = 0
+	e.improvedAt = e.iteration
+	e.training, e.validation = nil, nil
+	e.validationScore = 0
+	e.probes, e.noveltyArchive, e.novelty = nil, nil, nil
+	if e.hallOfFame != nil {
+		e.hallOfFame = NewHallOfFame(e.cfg.HallOfFame)
+	}
+}
+
+// Breed the next generation and write a checkpoint when one is due.
+func (e *Evolver) advance() {
+	elites := e.elites()
+
+	// Config.Validate has checked the name.
+	if sel, _ := NewSelection(e.cfg); sel != nil {
+		e.nextGeneration(sel, elites)
+	} else {
+		e.mutateFormsBucketStrategy(elites)
+	}
+
+	if e.checkpointDir != "" && e.iteration % e.checkpointEvery == 0 {
+		if err := e.saveCheckpoint(); err != nil {
+			fmt.Fprintln(os.Stderr, "Checkpoint failed:", err)
+		}
+	}
+}
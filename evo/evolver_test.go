@@ -0,0 +1,117 @@
+package evo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Evolver with a small population for tests.
+func testEvolver(t testing.TB, p ProblemInterface, forms int, seed int64, workers int) Evolver {
+	c := DefaultConfig()
+	c.Forms = forms
+	c.Seed = seed
+	c.Workers = workers
+
+	e, err := NewEvolver(p, c)
+	require.NoError(t, err)
+	return e
+}
+
+// Two runs with the same seed make the same decisions.
+func TestEvolverSeedReproducible(t *testing.T) {
+	var problem AdditionProblem
+
+	run := func(seed int64) Evolver {
+		e := testEvolver(t, problem, 200, seed, 0)
+		for i := 0; i < 5; i++ {
+			e.runIteration()
+			e.doBookKeeping()
+			e.mutateFormsBucketStrategy(nil)
+		}
+		return e
+	}
+
+	a := run(42)
+	b := run(42)
+	c := run(43)
+
+	require.Equal(t, len(a.forms), len(b.forms))
+	same := true
+	for i := range a.forms {
+		assert.Equal(t, a.forms[i].instructions, b.forms[i].instructions)
+		assert.Equal(t, a.forms[i].scoreSum, b.forms[i].scoreSum)
+		if !sameProgram(a.forms[i].instructions, c.forms[i].instructions) || a.forms[i].scoreSum != c.forms[i].scoreSum {
+			same = false
+		}
+	}
+	assert.False(t, same, "a different seed should give a different run")
+}
+
+// The bucket strategy breeds every slot of a bucket from the bucket's best
+// form.  Slots past the last whole bucket are kept and scored afresh.
+func TestBucketStrategy(t *testing.T) {
+	e := testEvolver(t, CopyProblem{}, 125, 1, 1)
+	e.cfg.MutationRate = 1 << 30 // Children are copies.
+	for i := range e.forms {
+		e.forms[i] = scoredForm(e.cfg, i, -10, 1)
+		e.forms[i].caseScores = []float64{-10}
+	}
+	e.forms[3] = scoredForm(e.cfg, 3, 0, 1)
+
+	e.mutateFormsBucketStrategy(nil)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, NewInstruction(SETVAL, 0, 3), e.forms[i].instructions[0], "slot %d", i)
+	}
+	for i := 120; i < len(e.forms); i++ {
+		assert.Equal(t, NewInstruction(SETVAL, 0, i), e.forms[i].instructions[0], "slot %d", i)
+	}
+	for i := range e.forms {
+		assert.Equal(t, 0, e.forms[i].runCount, "slot %d", i)
+		assert.Nil(t, e.forms[i].caseScores, "slot %d", i)
+	}
+}
+
+// Parallel evaluation gives the same scores as sequential evaluation.
+func TestEvolverWorkersMatchSequential(t *testing.T) {
+	var problem CopyProblem
+
+	run := func(workers int) Evolver {
+		e := testEvolver(t, problem, 500, 7, workers)
+		for i := 0; i < 3; i++ {
+			e.runIteration()
+			e.doBookKeeping()
+			e.mutateFormsBucketStrategy(nil)
+		}
+		e.runIteration()
+		return e
+	}
+
+	seq := run(1)
+	par := run(8)
+	for i := range seq.forms {
+		assert.Equal(t, seq.forms[i].scoreSum, par.forms[i].scoreSum)
+		assert.Equal(t, seq.forms[i].costSum, par.forms[i].costSum)
+	}
+}
+
+func benchmarkRunIteration(b *testing.B, workers int) {
+	var problem AdditionProblem
+
+	e := testEvolver(b, problem, MAXFORMS, 1, workers)
+	for i := range e.forms {
+		e.forms[i] = NewRandomForm(e.cfg, e.rng)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.runIteration()
+	}
+	b.ReportMetric(float64(b.N*len(e.forms)*e.cfg.RaceTrials)/b.Elapsed().Seconds(), "runs/s")
+}
+
+func BenchmarkRunIteration1(b *testing.B) { benchmarkRunIteration(b, 1) }
+func BenchmarkRunIteration2(b *testing.B) { benchmarkRunIteration(b, 2) }
+func BenchmarkRunIteration4(b *testing.B) { benchmarkRunIteration(b, 4) }
+func BenchmarkRunIteration8(b *testing.B) { benchmarkRunIteration(b, 8) }
@@ -0,0 +1,245 @@
+package evo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Integer arithmetic expressions over a problem's inputs, used by problem
+// files to define answers:
+//
+//	in0 + in1 * 2
+//	abs(in0 - in1) % 7
+//	max(in0, min(in1, -in2))
+//
+// Inputs are in0, in1, ...  Operators are + - * / % with the usual
+// precedence, unary minus and parentheses; functions are abs, min and max.
+// Division truncates like Go's, and division or remainder by zero gives 0
+// rather than failing so every input has an answer.
+type expr interface {
+	eval(in []int) int
+}
+
+type constExpr int
+
+type inputExpr int
+
+type negExpr struct {
+	x expr
+}
+
+type binaryExpr struct {
+	op   string
+	x, y expr
+}
+
+type callExpr struct {
+	fn   string
+	args []expr
+}
+
+func (e constExpr) eval(in []int) int { return int(e) }
+
+func (e inputExpr) eval(in []int) int { return in[e] }
+
+func (e negExpr) eval(in []int) int { return -e.x.eval(in) }
+
+func (e binaryExpr) eval(in []int) int {
+	x, y := e.x.eval(in), e.y.eval(in)
+	switch e.op {
+	case "+":
+		return x + y
+	case "-":
+		return x - y
+	case "*":
+		return x * y
+	case "/":
+		if y == 0 {
+			return 0
+		}
+		return x / y
+	}
+	if y == 0 {
+		return 0
+	}
+	return x % y
+}
+
+func (e callExpr) eval(in []int) int {
+	v := e.args[0].eval(in)
+	switch e.fn {
+	case "abs":
+		if v < 0 {
+			return -v
+		}
+	case "min":
+		for _, a := range e.args[1:] {
+			if w := a.eval(in); w < v {
+				v = w
+			}
+		}
+	case "max":
+		for _, a := range e.args[1:] {
+			if w := a.eval(in); w > v {
+				v = w
+			}
+		}
+	}
+	return v
+}
+
+// Parse an expression over inputs in0 to in(inputs-1).
+func parseExpr(s string, inputs int) (expr, error) {
+	p := &exprParser{tokens: tokenize(s), inputs: inputs}
+	e, err := p.sum()
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %v", s, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("expression %q: unexpected %q", s, p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// Split s into numbers, names and single character operators.
+func tokenize(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			tokens = append(tokens, s[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+// Recursive descent parser, one method per precedence level.
+type exprParser struct {
+	tokens []string
+	pos    int
+	inputs int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) expect(token string) error {
+	if p.peek() != token {
+		if p.peek() == "" {
+			return fmt.Errorf("missing %q", token)
+		}
+		return fmt.Errorf("expected %q, got %q", token, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// sum = product { ("+" | "-") product }
+func (p *exprParser) sum() (expr, error) {
+	x, err := p.product()
+	for err == nil && (p.peek() == "+" || p.peek() == "-") {
+		op := p.tokens[p.pos]
+		p.pos++
+		var y expr
+		if y, err = p.product(); err == nil {
+			x = binaryExpr{op, x, y}
+		}
+	}
+	return x, err
+}
+
+// product = unary { ("*" | "/" | "%") unary }
+func (p *exprParser) product() (expr, error) {
+	x, err := p.unary()
+	for err == nil && (p.peek() == "*" || p.peek() == "/" || p.peek() == "%") {
+		op := p.tokens[p.pos]
+		p.pos++
+		var y expr
+		if y, err = p.unary(); err == nil {
+			x = binaryExpr{op, x, y}
+		}
+	}
+	return x, err
+}
+
+// unary = "-" unary | operand
+func (p *exprParser) unary() (expr, error) {
+	if p.peek() == "-" {
+		p.pos++
+		x, err := p.unary()
+		return negExpr{x}, err
+	}
+	return p.operand()
+}
+
+// operand = number | input | function "(" sum { "," sum } ")" | "(" sum ")"
+func (p *exprParser) operand() (expr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end")
+	case token == "(":
+		p.pos++
+		x, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case unicode.IsDigit(rune(token[0])):
+		p.pos++
+		v, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", token)
+		}
+		return constExpr(v), nil
+	case strings.HasPrefix(token, "in"):
+		p.pos++
+		i, err := strconv.Atoi(token[2:])
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("unknown name %q", token)
+		}
+		if i >= p.inputs {
+			return nil, fmt.Errorf("%s but the problem has %d inputs", token, p.inputs)
+		}
+		return inputExpr(i), nil
+	case token == "abs" || token == "min" || token == "max":
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		call := callExpr{fn: token}
+		for {
+			x, err := p.sum()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, x)
+			if p.peek() != "," {
+				break
+			}
+			p.pos++
+		}
+		if token == "abs" && len(call.args) != 1 {
+			return nil, fmt.Errorf("abs takes 1 argument, got %d", len(call.args))
+		}
+		return call, p.expect(")")
+	}
+	return nil, fmt.Errorf("unexpected %q", token)
+}
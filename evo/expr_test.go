@@ -0,0 +1,51 @@
+package evo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpr(t *testing.T) {
+	in := []int{7, -3, 2}
+	cases := map[string]int{
+		"42":                       42,
+		"in0 + in1 * 2":            1,
+		"(in0 + in1) * 2":          8,
+		"in0 - in1 - in2":          8,
+		"-in0":                     -7,
+		"--in0":                    7,
+		"in0 / in2":                3,
+		"in1 / in2":                -1, // Truncated towards zero.
+		"in0 % in2":                1,
+		"in0 / 0 + in0 % 0":        0,
+		"abs(in1)":                 3,
+		"min(in0, in1, in2)":       -3,
+		"max(in0, min(in1, -in2))": 7,
+		"2 * -in1":                 6,
+	}
+	for s, want := range cases {
+		e, err := parseExpr(s, len(in))
+		require.NoError(t, err, s)
+		assert.Equal(t, want, e.eval(in), s)
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"in0 +",
+		"(in0",
+		"in0)",
+		"in3",
+		"x",
+		"abs(in0, in1)",
+		"max in0",
+		"in0 in1",
+		"1.5",
+	} {
+		_, err := parseExpr(s, 3)
+		assert.Error(t, err, "%q", s)
+	}
+}
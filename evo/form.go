@@ -2,10 +2,14 @@ package evo
 
 import (
 	"fmt"
+	"math/rand"
 	"strconv"
 )
 
 type Form struct {
+	// Sizes, mutation rate and the instruction set the form is built with.
+	cfg *Config
+
 	instructions []Instruction
 	mem          []int
 	input        []int
@@ -18,15 +22,16 @@ type Form struct {
 	runCount int
 	costSum int
 
+	// Score of each run since the stats were last reset, for selections
+	// that judge forms case by case.
+	caseScores []float64
+
 	// Code pointer.
 	cp int
-}
 
-const CODESIZE = 10
-const MEMSIZE = 10
-const IOSIZE = 10
-const MAXOPS = 10
-const MUTATIONRATE = 50
+	// Records execution while features are measured; nil otherwise.
+	trace *trace
+}
 
 
 func (f *Form) Description() string {
@@ -38,7 +43,7 @@ func (f *Form) Description() string {
 	}
 	desc += "Code:\n"
 	for i:=0; i<len(f.instructions); i++ {
-			desc += "  " + strconv.Itoa(i) + " : " + f.instructions[i].getDesc() + "\n"
+			desc += "  " + strconv.Itoa(i) + " : " + f.cfg.InstructionSet.Describe(f.instructions[i]) + "\n"
 	}
 	desc += "Output (zeros suppressed):\n"
 	for i:=0; i<len(f.output); i++ {
@@ -60,11 +65,11 @@ func (f *Form) Print() {
 	// fmt.Printf("%+v\n", f) // Print raw struct.
 }
 
-func NewNoopForm() Form {
-	f := Form{}
+func NewNoopForm(c *Config) Form {
+	f := Form{cfg: c}
 	f.init()
 
-	for i:=0; i < CODESIZE; i++ {
+	for i:=0; i < c.CodeSize; i++ {
 		f.instructions = append(f.instructions, Instruction{})
 	}
 
@@ -73,8 +78,8 @@ func NewNoopForm() Form {
 
 
 // A form which copies input0 to output0 (for testing).
-func NewCopyForm() Form {
-	f := Form{}
+func NewCopyForm(c *Config) Form {
+	f := Form{cfg: c}
 	f.init()
 
 	f.instructions = append(f.instructions, Instruction{ operation:COPYIN})
@@ -85,23 +90,34 @@ func NewCopyForm() Form {
 }
 
 
-func NewRandomForm() Form {
-	f := Form{}
+func NewRandomForm(c *Config, rng *rand.Rand) Form {
+	f := Form{cfg: c}
 	f.init()
 
-	for i:=0; i < CODESIZE; i++ {
-		f.instructions = append(f.instructions, NewRandomInstruction())
+	for i:=0; i < c.CodeSize; i++ {
+		f.instructions = append(f.instructions, NewRandomInstruction(c.InstructionSet, rng))
 	}
 
 	return f
 }
 
-// Create a new form based on a parent.  Mutation optional.
-func NewChildForm(parent Form, mutate bool) Form {
-	f := Form{}
+// Create a new form based on a parent.  Mutation optional.  The child uses
+// the parent's configuration and is as long as the parent, up to CodeSize
+// instructions, so programs of other lengths (e.g. from crossover) keep
+// their length.
+func NewChildForm(parent Form, mutate bool, rng *rand.Rand) Form {
+	c := parent.cfg
+	f := Form{cfg: c}
 	f.init()
 
-	f.instructions = make([]Instruction, CODESIZE)
+	length := len(parent.instructions)
+	if length > c.CodeSize {
+		length = c.CodeSize
+	}
+	if length < 1 {
+		length = 1
+	}
+	f.instructions = make([]Instruction, length)
 
 	pPos := 0
 	cPos := 0
@@ -110,18 +126,18 @@ func NewChildForm(parent Form, mutate bool) Form {
 
 		// Normal instruction copy with mutation.
 		if (mutate) {
-			f.instructions[cPos] = NewMutantInstruction(parent.instructions[pPos])
+			f.instructions[cPos] = NewMutantInstruction(c, parent.instructions[pPos], rng)
 		} else {
 			f.instructions[cPos] = parent.instructions[pPos].Copy()
 		}
 
 		// Skip or duplicate some of parent.
-		if (mutate && rng.Intn(MUTATIONRATE) == 0) {
-			pPos = rng.Intn(CODESIZE)
+		if (mutate && rng.Intn(c.MutationRate) == 0) {
+			pPos = rng.Intn(len(parent.instructions))
 		}
 		// Overwrite or skip part of child.
-		if (mutate && rng.Intn(MUTATIONRATE) == 0) {
-			cPos = rng.Intn(CODESIZE)
+		if (mutate && rng.Intn(c.MutationRate) == 0) {
+			cPos = rng.Intn(len(f.instructions))
 		}
 
 		pPos++
@@ -129,13 +145,29 @@ func NewChildForm(parent Form, mutate bool) Form {
 	}
 
 	// Fill reminder of child with random instructions
-	for ; cPos < CODESIZE ; cPos++ {
-		f.instructions = append(f.instructions, NewRandomInstruction())
+	for ; cPos < len(f.instructions) ; cPos++ {
+		f.instructions[cPos] = NewRandomInstruction(c.InstructionSet, rng)
 	}
 
 	return f
 }
 
+// Copy of the form's program.
+func (f *Form) Instructions() []Instruction {
+	return append([]Instruction(nil), f.instructions...)
+}
+
+// Deep copy of the form including its statistics.  The copy shares no
+// memory with the original.
+func (f *Form) Clone() Form {
+	c := *f
+	c.instructions = append([]Instruction(nil), f.instructions...)
+	c.mem = append([]int(nil), f.mem...)
+	c.output = append([]int(nil), f.output...)
+	c.caseScores = append([]float64(nil), f.caseScores...)
+	return c
+}
+
 func (f *Form) AvgScore() float64{
 	return f.scoreSum / float64(f.runCount)
 }
@@ -144,9 +176,21 @@ func (f *Form) AvgCost() float64{
 	return float64(f.costSum) / float64(f.runCount)
 }
 
+// Number of instructions that aren't noops, the program's effective length.
+func (f *Form) length() int {
+	n := 0
+	for i := range f.instructions {
+		if !f.instructions[i].noop() {
+			n++
+		}
+	}
+	return n
+}
+
 func (f *Form) init() {
-	f.output = make([]int, IOSIZE)
-	f.mem = make([]int, MEMSIZE)
+	f.cfg.instructionSet()
+	f.output = make([]int, f.cfg.IOSize)
+	f.mem = make([]int, f.cfg.MemSize)
 
 	f.reset()
 }
@@ -154,7 +198,7 @@ func (f *Form) init() {
 func (f *Form) reset() {
 	f.cp = 0
 	f.finished = false
-	f.opsleft = MAXOPS
+	f.opsleft = f.cfg.MaxOps
 
 	for i := 0; i < len(f.output) ; i++ {
 		f.output[i] = 0;
@@ -166,8 +210,19 @@ func (f *Form) reset() {
 }
 
 func (f *Form) resetStats() {
+	f.scoreSum = 0
 	f.costSum = 0
 	f.runCount = 0
+	f.caseScores = nil
+}
+
+// Run the program once against input and return a copy of its output.
+func (f *Form) Run(input []int) []int {
+	f.runCode(&input)
+
+	output := make([]int, len(f.output))
+	copy(output, f.output)
+	return output
 }
 
 func (f *Form) runCode(newInput *[]int) {
@@ -182,7 +237,7 @@ func (f *Form) runCode(newInput *[]int) {
 }
 
 func (f *Form) step() {
-	if (f.cp > CODESIZE || f.cp < 0) {
+	if (f.cp >= len(f.instructions) || f.cp < 0) {
 		f.finished = true
 		return
 	}
@@ -194,44 +249,72 @@ func (f *Form) step() {
 		}
 	}()
 
-	op := f.instructions[f.cp].operation
-
-	f.costSum += 10
+	ins := f.instructions[f.cp]
 
-	switch op {
-	case NOOP:
-		f.cp++
-		f.costSum -= 9 // Count a noop as a discount operation.
-	case JUMP:
-		f.jump()
-	case ADDLEQ:
-		f.addleq()
-	case DECNZJ:
-		f.decnzj()
-	case INCEQ:
-		f.inceq()
-	case COPYRES:
-		f.copyToResult()
-	case SETVAL:
-		f.setval()
-	case ENDEXEC:
-		f.endexec()
-		f.costSum -= 10 // Count endexec as free.
-	case COPYIN:
-		f.copyFromInput()
-	default:
+	op, ok := f.cfg.InstructionSet.Lookup(ins.operation)
+	if f.trace != nil {
+		f.trace.before(f, ins.operation, ok)
+		defer f.trace.after(f)
+	}
+	if !ok {
 		// Invalid operations end the program.
-		f.endexec()
+		f.costSum += INVALIDOPCOST
+		f.endexec(ins)
+		return
 	}
+
+	f.costSum += op.Cost
+	op.Exec(f, ins)
+}
+
+// Accessors for Operation executors.  Out of range addresses panic, which
+// ends the program.
+
+// Value of memory cell addr.
+func (f *Form) Mem(addr int) int {
+	return f.mem[addr]
+}
+
+// Set memory cell addr to value.
+func (f *Form) SetMem(addr int, value int) {
+	f.mem[addr] = value
+}
+
+// Value of input i.
+func (f *Form) Input(i int) int {
+	return f.input[i]
+}
+
+// Set output i to value.
+func (f *Form) SetOutput(i int, value int) {
+	f.output[i] = value
+}
+
+// Move on to the next instruction.
+func (f *Form) Advance() {
+	f.cp++
+}
+
+// Move the code pointer to cp.
+func (f *Form) JumpTo(cp int) {
+	f.cp = cp
+}
+
+// Stop the program.
+func (f *Form) Halt() {
+	f.finished = true
+}
+
+func (f *Form) noop(ins Instruction) {
+	f.cp++
 }
 
 // Move cp to p1.
-func (f *Form) jump() {
-	f.cp = f.instructions[f.cp].p1
+func (f *Form) jump(ins Instruction) {
+	f.cp = ins.p1
 }
 
-func (f *Form) addleq() {
-	ins := f.instructions[f.cp]
+func (f *Form) addleq(ins Instruction) {
 	f.mem[ins.p1] += f.mem[ins.p2]
 
 	if f.mem[ins.p1] <= 0 {
@@ -241,9 +324,7 @@ func (f *Form) addleq() {
 	}
 }
 
-func (f *Form) decnzj() {
-	ins := f.instructions[f.cp]
-
+func (f *Form) decnzj(ins Instruction) {
 	f.mem[ins.p1] = f.mem[ins.p1] - f.mem[ins.p2]
 	if f.mem[ins.p1] < 0 {
 		f.cp = ins.p3
@@ -252,9 +333,7 @@ func (f *Form) decnzj() {
 	}
 }
 
-func (f *Form) inceq() {
-	ins := f.instructions[f.cp]
-
+func (f *Form) inceq(ins Instruction) {
 	f.mem[ins.p1] += 1
 	if (f.mem[ins.p1] == f.mem[ins.p2]) {
 		f.cp = ins.p3
@@ -263,9 +342,7 @@ func (f *Form) inceq() {
 	}
 }
 
-func (f *Form) subleq() {
-	ins := f.instructions[f.cp]
-
+func (f *Form) subleq(ins Instruction) {
 	f.mem[ins.p1] -= f.mem[ins.p2]
 	if (f.mem[ins.p1] <= f.mem[ins.p3]) {
 		f.cp = ins.p4
@@ -274,28 +351,22 @@ func (f *Form) subleq() {
 	}
 }
 
-func (f *Form) copyToResult() {
-	ins := f.instructions[f.cp]
-
+func (f *Form) copyToResult(ins Instruction) {
 	f.output[ins.p2] = f.mem[ins.p1]
 	f.cp++
 }
 
-func (f *Form) copyFromInput() {
-	ins := f.instructions[f.cp]
-
+func (f *Form) copyFromInput(ins Instruction) {
 	f.mem[ins.p2] = f.input[ins.p1]
 	f.cp++
 }
 
-func (f *Form) setval() {
-	ins := f.instructions[f.cp]
-
+func (f *Form) setval(ins Instruction) {
 	f.mem[ins.p1] = ins.p2
 	f.cp++
 }
 
-func (f *Form) endexec() {
+func (f *Form) endexec(ins Instruction) {
 	f.finished = true
 }
 
@@ -316,3 +387,8 @@ func (f ByAvgScore) Less(i, j int) bool {
 	return f[i].AvgScore() > f[j].AvgScore()
 }
 
+// Population fitness is the average score.
+func (f ByAvgScore) Fitness(i int) float64 {
+	return f[i].AvgScore()
+}
+
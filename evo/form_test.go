@@ -1,6 +1,7 @@
 package evo
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,13 +9,25 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// Deterministic random number generator for tests.
+func testRand() *rand.Rand {
+	return rand.New(newSource(1))
+}
+
+// Default configuration for tests.
+func testConfig() *Config {
+	c := DefaultConfig()
+	c.InstructionSet = DefaultInstructionSet()
+	return &c
+}
+
 // Check basic form copy operation and ensure modification to parent post-copy
 // does not modify child.
 func TestForm(t *testing.T) {
-	tf := NewRandomForm()
+	tf := NewRandomForm(testConfig(), testRand())
 	tf.instructions[0].operation = NOOP
 
-	CopyForm := NewChildForm(tf, false)
+	CopyForm := NewChildForm(tf, false, testRand())
 
 	for i:=0; i < CODESIZE; i++ {
 		assert.Equal(t, tf.instructions[i], CopyForm.instructions[i], "should match")
@@ -27,7 +40,7 @@ func TestForm(t *testing.T) {
 
 
 func TestFormProgramIOCopy(t *testing.T) {
-	f := NewRandomForm()
+	f := NewRandomForm(testConfig(), testRand())
 
 	f.instructions[0].operation = COPYIN
 	f.instructions[0].p1 = 0
@@ -47,7 +60,7 @@ func TestFormProgramIOCopy(t *testing.T) {
 
 func TestFormProgramInvalidRange(t *testing.T) {
 	// Check that the program completes despite an out-of-range issue.
-	f := NewRandomForm()
+	f := NewRandomForm(testConfig(), testRand())
 
 	f.instructions[0].operation = COPYIN
 	f.instructions[0].p1 = 0
@@ -74,14 +87,14 @@ func TestFormProgramInvalidRange(t *testing.T) {
 }
 
 func TestFormPrint(t *testing.T) {
-	f := NewRandomForm()
+	f := NewRandomForm(testConfig(), testRand())
 	f.Print()
 }
 
 func TestFormSort(t *testing.T) {
-	f1 := NewNoopForm()
-	f2 := NewNoopForm()
-	f3 := NewNoopForm()
+	f1 := NewNoopForm(testConfig())
+	f2 := NewNoopForm(testConfig())
+	f3 := NewNoopForm(testConfig())
 
 	// Using opsleft to identify forms easily.
 	f1.opsleft=1
@@ -0,0 +1,143 @@
+package evo
+
+import (
+	"sort"
+	"sync"
+)
+
+// A program kept by a HallOfFame.
+type HallOfFameEntry struct {
+	// Copy of the form, with its statistics summed over every evaluation
+	// of the program the hall was offered.
+	Form Form
+
+	// Mean score and cost over those evaluations.
+	Score float64
+	Cost  float64
+
+	// Iteration the program was first seen in.
+	Generation int
+}
+
+// A HallOfFame keeps the best distinct programs ever seen, however long ago.
+// Programs with the same instructions are one entry, ignoring parameters
+// the operations don't read, and each is judged on the mean of all its
+// evaluations rather than its luckiest.  It's safe to query while a run adds
+// to it.
+type HallOfFame struct {
+	mu      sync.Mutex
+	size    int
+	entries []HallOfFameEntry
+}
+
+// A hall of fame holding up to size programs.
+func NewHallOfFame(size int) *HallOfFame {
+	return &HallOfFame{size: size}
+}
+
+// Maximum number of programs kept.
+func (h *HallOfFame) Size() int {
+	return h.size
+}
+
+// Number of programs kept.
+func (h *HallOfFame) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Copy of the entries, best first.
+func (h *HallOfFame) Entries() []HallOfFameEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make([]HallOfFameEntry, len(h.entries))
+	for i, en := range h.entries {
+		en.Form = en.Form.Clone()
+		entries[i] = en
+	}
+	return entries
+}
+
+// Offer an evaluated form seen in generation.  Returns whether it was
+// admitted or pooled with an existing entry.  A program already present
+// adds the form's evaluations to its own and keeps the generation it was
+// first seen in.
+func (h *HallOfFame) Add(f Form, generation int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.add(f, generation)
+}
+
+// Offer every form of an evaluated generation.
+func (h *HallOfFame) addAll(forms []Form, generation int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range forms {
+		h.add(forms[i], generation)
+	}
+}
+
+func (h *HallOfFame) add(f Form, generation int) bool {
+	if h.size < 1 || f.runCount == 0 {
+		return false
+	}
+
+	for i := range h.entries {
+		en := &h.entries[i]
+		if !sameCode(f.cfg.instructionSet(), en.Form.instructions, f.instructions) {
+			continue
+		}
+		en.Form.scoreSum += f.scoreSum
+		en.Form.costSum += f.costSum
+		en.Form.runCount += f.runCount
+		en.Score, en.Cost = en.Form.AvgScore(), en.Form.AvgCost()
+		if generation < en.Generation {
+			en.Generation = generation
+		}
+		h.sort()
+		return true
+	}
+
+	candidate := HallOfFameEntry{Score: f.AvgScore(), Cost: f.AvgCost(), Generation: generation}
+	if len(h.entries) == h.size && !candidate.better(h.entries[len(h.entries)-1]) {
+		return false
+	}
+	candidate.Form = f.Clone()
+	candidate.Form.caseScores = nil
+	h.entries = append(h.entries, candidate)
+	h.sort()
+	if len(h.entries) > h.size {
+		h.entries = h.entries[:h.size]
+	}
+	return true
+}
+
+func (h *HallOfFame) sort() {
+	sort.SliceStable(h.entries, func(i, j int) bool {
+		return h.entries[i].better(h.entries[j])
+	})
+}
+
+// Higher score wins, then lower cost.
+func (en HallOfFameEntry) better(other HallOfFameEntry) bool {
+	if en.Score != other.Score {
+		return en.Score > other.Score
+	}
+	return en.Cost < other.Cost
+}
+
+// Do the programs run the same instructions under isa?  Parameters an
+// operation doesn't read don't count.
+func sameCode(isa *InstructionSet, a, b []Instruction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if isa.normalize(a[i]) != isa.normalize(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package evo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Form setting mem0 to value, scored as if run once.
+func scoredForm(c *Config, value int, score float64, cost int) Form {
+	f := NewNoopForm(c)
+	f.instructions[0] = NewInstruction(SETVAL, 0, value)
+	f.scoreSum = score
+	f.costSum = cost
+	f.runCount = 1
+	return f
+}
+
+// Are the programs identical, unread parameters and all?
+func sameProgram(a, b []Instruction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHallOfFame(t *testing.T) {
+	c := testConfig()
+	h := NewHallOfFame(3)
+
+	assert.True(t, h.Add(scoredForm(c, 1, -5, 3), 0))
+	assert.True(t, h.Add(scoredForm(c, 2, -1, 3), 1))
+	assert.True(t, h.Add(scoredForm(c, 3, -3, 3), 2))
+	assert.Equal(t, 3, h.Len())
+
+	// Worse than everything kept.
+	assert.False(t, h.Add(scoredForm(c, 4, -9, 3), 3))
+
+	// Pushes out the worst.
+	assert.True(t, h.Add(scoredForm(c, 5, -2, 3), 4))
+
+	// Same program again: not a new entry, but its evaluations are pooled
+	// and it keeps the generation it was first seen in.
+	assert.True(t, h.Add(scoredForm(c, 2, -4, 3), 5))
+	entries := h.Entries()
+	require.Equal(t, 3, len(entries))
+	assert.Equal(t, -2.0, entries[0].Score)
+	assert.Equal(t, -2.5, entries[1].Score)
+	assert.Equal(t, 1, entries[1].Generation)
+	assert.Equal(t, 2, entries[1].Form.runCount)
+	assert.Equal(t, -3.0, entries[2].Score)
+
+	// Parameters the operation doesn't read don't make a program distinct.
+	same := scoredForm(c, 2, 0, 3)
+	same.instructions[0].p3 = 77
+	same.instructions[1].p1 = 5
+	assert.True(t, h.Add(same, 6))
+	entries = h.Entries()
+	require.Equal(t, 3, len(entries))
+	assert.InDelta(t, -5.0/3, entries[0].Score, 1e-9)
+	assert.Equal(t, 1, entries[0].Generation)
+	assert.Equal(t, -2.0, entries[1].Score)
+	assert.Equal(t, 4, entries[1].Generation)
+
+	// Ties on score go to the cheaper program.
+	h = NewHallOfFame(2)
+	h.Add(scoredForm(c, 1, 0, 9), 0)
+	h.Add(scoredForm(c, 2, 0, 4), 0)
+	assert.Equal(t, 4.0, h.Entries()[0].Cost)
+
+	// Entries are copies.
+	entries = h.Entries()
+	entries[0].Form.instructions[0] = Instruction{}
+	assert.NotEqual(t, Instruction{}, h.Entries()[0].Form.instructions[0])
+
+	// Unevaluated forms are ignored.
+	assert.False(t, h.Add(NewNoopForm(c), 0))
+}
+
+func TestEvolverHallOfFame(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 200
+	c.StabilityDuration = 5
+	c.Seed = 3
+	c.Workers = 1
+	c.HallOfFame = 5
+
+	e, err := NewEvolver(Output1Problem{}, c)
+	require.NoError(t, err)
+	e.StopWhen(SolvedAndStable(), MaxIterations(500))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, res.Solved)
+	require.Equal(t, 5, len(res.HallOfFame))
+	assert.Equal(t, 0.0, res.HallOfFame[0].Score)
+	for i := 1; i < len(res.HallOfFame); i++ {
+		assert.False(t, sameCode(e.cfg.InstructionSet, res.HallOfFame[0].Form.instructions, res.HallOfFame[i].Form.instructions))
+	}
+
+	// Survives a checkpoint.
+	var buf bytes.Buffer
+	require.NoError(t, e.Checkpoint(&buf))
+	r, err := LoadEvolver(&buf, Output1Problem{})
+	require.NoError(t, err)
+	loaded := r.HallOfFame().Entries()
+	require.Equal(t, len(res.HallOfFame), len(loaded))
+	for i, en := range loaded {
+		assert.Equal(t, res.HallOfFame[i].Form.instructions, en.Form.instructions)
+		assert.Equal(t, res.HallOfFame[i].Score, en.Score)
+		assert.Equal(t, res.HallOfFame[i].Cost, en.Cost)
+		assert.Equal(t, res.HallOfFame[i].Generation, en.Generation)
+	}
+
+	e, err = NewEvolver(Output1Problem{}, DefaultConfig())
+	require.NoError(t, err)
+	assert.Nil(t, e.HallOfFame())
+}
+
+func TestElites(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 50
+	c.Seed = 4
+	c.Workers = 1
+	c.Elites = 3
+	c.Selection = "tournament"
+
+	e, err := NewEvolver(CopyProblem{}, c)
+	require.NoError(t, err)
+	for i := range e.forms {
+		e.forms[i] = NewRandomForm(e.cfg, e.rng)
+	}
+	e.runIteration()
+
+	order := rankOrder(ByAvgScore(e.forms))
+	var want [][]Instruction
+	for _, i := range order[:3] {
+		want = append(want, e.forms[i].Instructions())
+	}
+
+	// Elites take the last slots, after the bred children.
+	e.advance()
+	require.Equal(t, c.Forms, len(e.forms))
+	last := e.forms[len(e.forms)-3:]
+	for i := range want {
+		assert.Equal(t, want[i], last[i].instructions)
+		assert.Equal(t, 0, last[i].runCount)
+		assert.Equal(t, 0.0, last[i].scoreSum)
+	}
+
+	c.Elites = c.Forms
+	assert.Error(t, c.Validate())
+}
+
+func TestElitesKeepBucketWinners(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 120
+	c.Seed = 4
+	c.Workers = 1
+	c.Elites = 3
+
+	e, err := NewEvolver(CopyProblem{}, c)
+	require.NoError(t, err)
+	for i := range e.forms {
+		e.forms[i] = scoredForm(e.cfg, i, -float64(i), 1)
+	}
+
+	// Form 0 is the best overall and bucket 0's winner; the elites mustn't
+	// replace it, nor the other buckets' winners.
+	e.advance()
+	assert.Equal(t, NewInstruction(SETVAL, 0, 0), e.forms[0].instructions[0])
+	for k, f := range e.forms[len(e.forms)-3:] {
+		assert.Equal(t, NewInstruction(SETVAL, 0, k), f.instructions[0])
+	}
+}
@@ -2,10 +2,9 @@ package evo
 
 import (
 	"math/rand"
-	"time"
-	"strconv"
 )
 
+// Opcodes of the DefaultInstructionSet.
 const NOOP = 0;
 const JUMP = 1;
 const ADDLEQ = 2;
@@ -32,62 +31,53 @@ type Instruction struct {
 	p4 int
 }
 
-func (i *Instruction) getDesc() string {
-
-	var desc string
-	var longdesc string
-
-	switch i.operation {
-	case NOOP:
-		desc = "noop"
-		longdesc = "do nothing"
-	case JUMP:
-		desc = "jump " + strconv.Itoa(i.p1)
-		longdesc = "jump to code" + strconv.Itoa(i.p1)
-	case ADDLEQ:
-		desc = "addleq " + strconv.Itoa(i.p1) + " " + strconv.Itoa(i.p2) + " " + strconv.Itoa(i.p3)
-		longdesc = "mem" + strconv.Itoa(i.p1) + "+= mem" + strconv.Itoa(i.p2) + "; if mem" + strconv.Itoa(i.p1) + " <= 0 jump to code" + strconv.Itoa(i.p3)
-	case DECNZJ:
-		desc = "decnzj " + strconv.Itoa(i.p1) + " " + strconv.Itoa(i.p2) + " " + strconv.Itoa(i.p3)
-		longdesc = "mem" + strconv.Itoa(i.p1) + "-= mem" + strconv.Itoa(i.p2) + "; if mem" + strconv.Itoa(i.p1) + " !=0 jump to code" + strconv.Itoa(i.p3)
-	case INCEQ:
-		desc = "inceq " + strconv.Itoa(i.p1) + " " + strconv.Itoa(i.p2) + " " + strconv.Itoa(i.p3)
-		longdesc = "mem" + strconv.Itoa(i.p1) + "++; if mem" + strconv.Itoa(i.p1) + "==mem" + strconv.Itoa(i.p2) + " jump to code" + strconv.Itoa(i.p3)
-	case COPYRES:
-		desc = "copyres " + strconv.Itoa(i.p1) + " " + strconv.Itoa(i.p2)
-		longdesc = "output" + strconv.Itoa(i.p2) + "=mem" + strconv.Itoa(i.p1)
-	case SETVAL:
-		desc = "setval " + strconv.Itoa(i.p1) + " " + strconv.Itoa(i.p2)
-		longdesc = "mem" + strconv.Itoa(i.p1) + "=" + strconv.Itoa(i.p2)
-	case ENDEXEC:
-		desc = "endexec"
-		longdesc = "stop program"
-	case COPYIN:
-		desc = "copyin " + strconv.Itoa(i.p1) + " " + strconv.Itoa(i.p2)
-		longdesc = "mem" + strconv.Itoa(i.p2) + "=input" + strconv.Itoa(i.p1)
-	default:
-		desc = "invalid(op" + strconv.Itoa(i.operation) + ")"
-		longdesc = "invalid operation code " + strconv.Itoa(i.operation)
+// Build an instruction from an opcode and up to four parameters.
+func NewInstruction(operation int, params ...int) Instruction {
+	ins := Instruction{operation: operation}
+	for n, v := range params {
+		ins.setParam(n+1, v)
 	}
+	return ins
+}
 
-	desc = desc + "\t(" + longdesc + ")"
-	return desc
+// The operation code.
+func (i Instruction) Op() int {
+	return i.operation
 }
 
-func (i *Instruction) noop() bool {
-	if i.operation == NOOP {
-		return true
+// Parameter n (1 through 4); 0 for any other n.
+func (i Instruction) Param(n int) int {
+	switch n {
+	case 1:
+		return i.p1
+	case 2:
+		return i.p2
+	case 3:
+		return i.p3
+	case 4:
+		return i.p4
 	}
-	return false
+	return 0
 }
 
-// Is this instruction valid?
-func (i *Instruction) valid() bool {
-	if i.operation < 0 || i.operation > MAX_OPERATION {
-		return false
+func (i *Instruction) setParam(n int, v int) {
+	switch n {
+	case 1:
+		i.p1 = v
+	case 2:
+		i.p2 = v
+	case 3:
+		i.p3 = v
+	case 4:
+		i.p4 = v
 	}
+}
 
-	return true
+func (i *Instruction) noop() bool {
+	if i.operation == NOOP {
+		return true
+	}
+	return false
 }
 
 func (i *Instruction) Copy() Instruction {
@@ -102,11 +92,10 @@ func (i *Instruction) Copy() Instruction {
 	return newins
 }
 
-func NewRandomInstruction() Instruction {
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-
+// A random instruction drawn from the operations of the instruction set.
+func NewRandomInstruction(isa *InstructionSet, rng *rand.Rand) Instruction {
 	ins := Instruction{}
-	ins.operation = rng.Intn(MAX_OPERATION)
+	ins.operation = rng.Intn(isa.Len())
 	ins.p1 = rng.Intn(MAX_PARAM - MIN_PARAM) + MIN_PARAM
 	ins.p2 = rng.Intn(MAX_PARAM - MIN_PARAM) + MIN_PARAM
 	ins.p3 = rng.Intn(MAX_PARAM - MIN_PARAM) + MIN_PARAM
@@ -115,26 +104,31 @@ func NewRandomInstruction() Instruction {
 	return ins
 }
 
-func NewMutantInstruction(parent Instruction) Instruction {
+// Copy of parent with occasional changes at the configured mutation rate.
+// The operation is only ever replaced with another operation from the
+// configured instruction set.
+func NewMutantInstruction(c *Config, parent Instruction, rng *rand.Rand) Instruction {
 	ins := parent.Copy()
 
-	maybeMutate(&ins.operation, MUTATIONRATE)
-	maybeMutate(&ins.p1, MUTATIONRATE)
-	maybeMutate(&ins.p2, MUTATIONRATE)
-	maybeMutate(&ins.p3, MUTATIONRATE)
-	maybeMutate(&ins.p4, MUTATIONRATE)
+	if rng.Intn(c.MutationRate) == 0 {
+		ins.operation = rng.Intn(c.InstructionSet.Len())
+	}
+	maybeMutate(&ins.p1, c.MutationRate, rng)
+	maybeMutate(&ins.p2, c.MutationRate, rng)
+	maybeMutate(&ins.p3, c.MutationRate, rng)
+	maybeMutate(&ins.p4, c.MutationRate, rng)
 
 	return ins
 }
 
-func maybeMutate(value *int, mutationOdds int) {
+func maybeMutate(value *int, mutationOdds int, rng *rand.Rand) {
 	// Increment/decrement value mutation.
-	if rng.Intn(MUTATIONRATE) == 0 {
+	if rng.Intn(mutationOdds) == 0 {
 		*value += rng.Intn(MAX_OPERATION * 2) - MAX_OPERATION;
 	}
 
 	// Sign flip mutation.
-	if rng.Intn(MUTATIONRATE) == 0 {
+	if rng.Intn(mutationOdds) == 0 {
 		*value = - *value
 	}
 }
\ No newline at end of file
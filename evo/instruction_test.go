@@ -22,7 +22,9 @@ func TestRandomInstruction(t *testing.T) {
 
 	var emptyIns1 Instruction
 	var emptyIns2 Instruction
-	randIns := NewRandomInstruction()
+	isa := DefaultInstructionSet()
+	rng := testRand()
+	randIns := NewRandomInstruction(isa, rng)
 
 	// Sanity check for instruction compairison.
 	assert.Equal(t, emptyIns1, emptyIns2, "Should be equal")
@@ -30,7 +32,7 @@ func TestRandomInstruction(t *testing.T) {
 	trials := 10
 	matches := 0
 	for i:= 0; i < trials; i++ {
-		randIns2 := NewRandomInstruction()
+		randIns2 := NewRandomInstruction(isa, rng)
 		if (randIns == randIns2) {
 			matches++
 		}
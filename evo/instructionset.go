@@ -0,0 +1,230 @@
+package evo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An Operation describes a single opcode of an InstructionSet; everything the
+// VM, the mutator and the disassembler need to know about it lives here.
+type Operation struct {
+	// Mnemonic used when describing or assembling the instruction, e.g. "copyin".
+	Name string
+
+	// Number of parameters (p1..p4) the operation reads.
+	Arity int
+
+	// Cost added to the form's costSum each time the operation is executed.
+	Cost int
+
+	// Parameter (1..Arity) holding a code address to jump to, or 0 if none.
+	// Used by the disassembler to write labels.
+	Target int
+
+	// Execute the instruction against the form.  The executor is responsible
+	// for moving the code pointer (Advance, JumpTo) or ending the program
+	// (Halt).  Out of range memory or io access panics, which ends the program.
+	Exec func(f *Form, ins Instruction)
+
+	// Long human readable description of what the instruction does.  Optional.
+	Describe func(ins Instruction) string
+}
+
+// An InstructionSet is the table of operations a Form can execute.  Opcodes
+// are assigned in registration order starting at 0.
+type InstructionSet struct {
+	ops   []Operation
+	names map[string]int
+}
+
+// Cost of executing an opcode that is not in the instruction set.  Invalid
+// operations end the program.
+const INVALIDOPCOST = 10
+
+func NewInstructionSet() *InstructionSet {
+	return &InstructionSet{names: map[string]int{}}
+}
+
+// Register adds an operation to the set and returns its opcode.
+func (s *InstructionSet) Register(op Operation) (int, error) {
+	if op.Name == "" || strings.ContainsAny(op.Name, " \t\n;:#") {
+		return 0, fmt.Errorf("invalid operation name %q", op.Name)
+	}
+	if _, ok := s.names[op.Name]; ok {
+		return 0, fmt.Errorf("operation %q already registered", op.Name)
+	}
+	if op.Arity < 0 || op.Arity > 4 {
+		return 0, fmt.Errorf("operation %q: arity %d not in [0, 4]", op.Name, op.Arity)
+	}
+	if op.Target < 0 || op.Target > op.Arity {
+		return 0, fmt.Errorf("operation %q: target parameter %d not in [0, %d]", op.Name, op.Target, op.Arity)
+	}
+	if op.Exec == nil {
+		return 0, fmt.Errorf("operation %q has no executor", op.Name)
+	}
+
+	s.ops = append(s.ops, op)
+	s.names[op.Name] = len(s.ops) - 1
+
+	return len(s.ops) - 1, nil
+}
+
+// MustRegister is like Register but panics on error.  Intended for building
+// instruction sets at init time.
+func (s *InstructionSet) MustRegister(op Operation) int {
+	code, err := s.Register(op)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// Number of operations in the set.  Valid opcodes are [0, Len()).
+func (s *InstructionSet) Len() int {
+	return len(s.ops)
+}
+
+// Lookup the operation for an opcode.
+func (s *InstructionSet) Lookup(opcode int) (Operation, bool) {
+	if opcode < 0 || opcode >= len(s.ops) {
+		return Operation{}, false
+	}
+	return s.ops[opcode], true
+}
+
+// Opcode for an operation name.
+func (s *InstructionSet) Opcode(name string) (int, bool) {
+	code, ok := s.names[name]
+	return code, ok
+}
+
+// Is the instruction's operation part of this set?
+func (s *InstructionSet) Valid(ins Instruction) bool {
+	_, ok := s.Lookup(ins.operation)
+	return ok
+}
+
+// The instruction as the set runs it: parameters past the operation's
+// Arity, which are never read, are zeroed, as are all the parameters of an
+// invalid operation.
+func (s *InstructionSet) normalize(ins Instruction) Instruction {
+	n := Instruction{operation: ins.operation}
+	if op, ok := s.Lookup(ins.operation); ok {
+		for k := 1; k <= op.Arity; k++ {
+			n.setParam(k, ins.Param(k))
+		}
+	}
+	return n
+}
+
+// Short form of the instruction, e.g. "copyin 0 1".
+func (s *InstructionSet) Mnemonic(ins Instruction) string {
+	op, ok := s.Lookup(ins.operation)
+	if !ok {
+		return "invalid(op" + strconv.Itoa(ins.operation) + ")"
+	}
+
+	desc := op.Name
+	for n := 1; n <= op.Arity; n++ {
+		desc += " " + strconv.Itoa(ins.Param(n))
+	}
+	return desc
+}
+
+// Short and long description of the instruction.
+func (s *InstructionSet) Describe(ins Instruction) string {
+	op, ok := s.Lookup(ins.operation)
+
+	var longdesc string
+	switch {
+	case !ok:
+		longdesc = "invalid operation code " + strconv.Itoa(ins.operation)
+	case op.Describe != nil:
+		longdesc = op.Describe(ins)
+	default:
+		longdesc = op.Name
+	}
+
+	return s.Mnemonic(ins) + "\t(" + longdesc + ")"
+}
+
+// The instruction set the package was built around.  Opcodes match the
+// NOOP..COPYIN constants.  Each call returns a new set so callers may extend
+// it with domain specific operations.
+func DefaultInstructionSet() *InstructionSet {
+	s := NewInstructionSet()
+
+	s.MustRegister(Operation{
+		Name: "noop", Arity: 0, Cost: 1, // Count a noop as a discount operation.
+		Exec: (*Form).noop,
+		Describe: func(ins Instruction) string {
+			return "do nothing"
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "jump", Arity: 1, Cost: 10, Target: 1,
+		Exec: (*Form).jump,
+		Describe: func(ins Instruction) string {
+			return "jump to code" + strconv.Itoa(ins.p1)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "addleq", Arity: 3, Cost: 10,
+		Exec: (*Form).addleq,
+		Describe: func(ins Instruction) string {
+			return "mem" + strconv.Itoa(ins.p1) + "+= mem" + strconv.Itoa(ins.p2) + "; if mem" + strconv.Itoa(ins.p1) + " <= 0 jump to code" + strconv.Itoa(ins.p3)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "decnzj", Arity: 3, Cost: 10, Target: 3,
+		Exec: (*Form).decnzj,
+		Describe: func(ins Instruction) string {
+			return "mem" + strconv.Itoa(ins.p1) + "-= mem" + strconv.Itoa(ins.p2) + "; if mem" + strconv.Itoa(ins.p1) + " !=0 jump to code" + strconv.Itoa(ins.p3)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "inceq", Arity: 3, Cost: 10, Target: 3,
+		Exec: (*Form).inceq,
+		Describe: func(ins Instruction) string {
+			return "mem" + strconv.Itoa(ins.p1) + "++; if mem" + strconv.Itoa(ins.p1) + "==mem" + strconv.Itoa(ins.p2) + " jump to code" + strconv.Itoa(ins.p3)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "subleq", Arity: 4, Cost: 10, Target: 4,
+		Exec: (*Form).subleq,
+		Describe: func(ins Instruction) string {
+			return "mem" + strconv.Itoa(ins.p1) + "-= mem" + strconv.Itoa(ins.p2) + "; if mem" + strconv.Itoa(ins.p1) + " <= mem" + strconv.Itoa(ins.p3) + " jump to code" + strconv.Itoa(ins.p4)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "copyres", Arity: 2, Cost: 10,
+		Exec: (*Form).copyToResult,
+		Describe: func(ins Instruction) string {
+			return "output" + strconv.Itoa(ins.p2) + "=mem" + strconv.Itoa(ins.p1)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "setval", Arity: 2, Cost: 10,
+		Exec: (*Form).setval,
+		Describe: func(ins Instruction) string {
+			return "mem" + strconv.Itoa(ins.p1) + "=" + strconv.Itoa(ins.p2)
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "endexec", Arity: 0, Cost: 0, // Count endexec as free.
+		Exec: (*Form).endexec,
+		Describe: func(ins Instruction) string {
+			return "stop program"
+		},
+	})
+	s.MustRegister(Operation{
+		Name: "copyin", Arity: 2, Cost: 10,
+		Exec: (*Form).copyFromInput,
+		Describe: func(ins Instruction) string {
+			return "mem" + strconv.Itoa(ins.p2) + "=input" + strconv.Itoa(ins.p1)
+		},
+	})
+
+	return s
+}
@@ -0,0 +1,88 @@
+package evo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultInstructionSetOpcodes(t *testing.T) {
+	isa := DefaultInstructionSet()
+
+	// The registry must keep the historic opcode numbering.
+	names := map[int]string{
+		NOOP: "noop", JUMP: "jump", ADDLEQ: "addleq", DECNZJ: "decnzj",
+		INCEQ: "inceq", SUBLEQ: "subleq", COPYRES: "copyres", SETVAL: "setval",
+		ENDEXEC: "endexec", COPYIN: "copyin",
+	}
+	assert.Equal(t, len(names), isa.Len())
+	for code, name := range names {
+		op, ok := isa.Lookup(code)
+		require.True(t, ok)
+		assert.Equal(t, name, op.Name)
+	}
+
+	_, ok := isa.Lookup(isa.Len())
+	assert.False(t, ok)
+}
+
+func TestInstructionSetRegister(t *testing.T) {
+	isa := DefaultInstructionSet()
+
+	_, err := isa.Register(Operation{Name: "noop", Exec: (*Form).noop})
+	assert.Error(t, err, "duplicate names are rejected")
+
+	_, err = isa.Register(Operation{Name: "bad name", Exec: (*Form).noop})
+	assert.Error(t, err, "names with whitespace are rejected")
+
+	_, err = isa.Register(Operation{Name: "nothing"})
+	assert.Error(t, err, "an executor is required")
+
+	// A domain specific operation: output0 = input0 * input1.
+	mul, err := isa.Register(Operation{
+		Name: "mulin", Arity: 1, Cost: 3,
+		Exec: func(f *Form, ins Instruction) {
+			f.SetOutput(ins.Param(1), f.Input(0)*f.Input(1))
+			f.Advance()
+		},
+	})
+	require.NoError(t, err)
+
+	c := DefaultConfig()
+	c.InstructionSet = isa
+	f := NewNoopForm(&c)
+	f.instructions[0] = NewInstruction(mul, 0)
+	f.instructions[1] = NewInstruction(ENDEXEC)
+
+	input := []int{6, 7}
+	f.runCode(&input)
+
+	assert.Equal(t, 42, f.output[0])
+	assert.Equal(t, 3, f.costSum)
+	assert.Equal(t, "mulin 0\t(mulin)", isa.Describe(f.instructions[0]))
+}
+
+func TestSubleqExecutes(t *testing.T) {
+	isa := DefaultInstructionSet()
+	c := DefaultConfig()
+	c.InstructionSet = isa
+	f := NewNoopForm(&c)
+
+	f.instructions[0] = NewInstruction(SETVAL, 0, 5)
+	f.instructions[1] = NewInstruction(SUBLEQ, 0, 0, 1, 3) // mem0 = 0 <= mem1; jump 3.
+	f.instructions[2] = NewInstruction(ENDEXEC)
+	f.instructions[3] = NewInstruction(SETVAL, 2, 9)
+	f.instructions[4] = NewInstruction(COPYRES, 2, 0)
+	f.instructions[5] = NewInstruction(ENDEXEC)
+
+	input := []int{}
+	f.runCode(&input)
+
+	assert.Equal(t, 9, f.output[0])
+}
+
+func TestDescribeInvalid(t *testing.T) {
+	isa := DefaultInstructionSet()
+	assert.Equal(t, "invalid(op99)\t(invalid operation code 99)", isa.Describe(NewInstruction(99)))
+}
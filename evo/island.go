@@ -0,0 +1,432 @@
+package evo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Migration topologies, see ArchipelagoConfig.Topology.
+const TOPOLOGYRING = "ring"
+const TOPOLOGYFULL = "full"
+const TOPOLOGYRANDOM = "random"
+
+// Names accepted by ArchipelagoConfig.Topology.
+func TopologyNames() []string {
+	return []string{TOPOLOGYFULL, TOPOLOGYRANDOM, TOPOLOGYRING}
+}
+
+// Settings for an Archipelago.
+type ArchipelagoConfig struct {
+	// Configuration of each island.  Islands may differ in any setting
+	// except the instruction set.  An island with Seed 0 is seeded from Seed;
+	// Workers 0 means 1 as the islands already run in parallel.
+	Islands []Config
+
+	// Iterations between migrations.
+	MigrationInterval int
+
+	// Best forms each island sends per migration.
+	Migrants int
+
+	// Where migrants go: TOPOLOGYRING sends to the next island,
+	// TOPOLOGYFULL to every other island and TOPOLOGYRANDOM to one other
+	// island chosen at random each migration.
+	Topology string
+
+	// Seed for the islands' seeds and random migration.  0 seeds from the
+	// clock.
+	Seed int64
+}
+
+// Settings for n islands configured alike, migrating the best 5 forms around
+// a ring every 25 iterations.
+func DefaultArchipelagoConfig(c Config, n int) ArchipelagoConfig {
+	ac := ArchipelagoConfig{
+		MigrationInterval: 25,
+		Migrants:          5,
+		Topology:          TOPOLOGYRING,
+		Seed:              c.Seed,
+	}
+	for i := 0; i < n; i++ {
+		island := c
+		island.Seed = 0
+		ac.Islands = append(ac.Islands, island)
+	}
+	return ac
+}
+
+// An Archipelago evolves several populations (islands) in parallel on the
+// same problem.  Islands evolve independently between migrations, when
+// copies of each island's best forms replace the worst forms of other
+// islands.  This keeps the diversity one large population loses as it
+// converges on a single lineage.
+type Archipelago struct {
+	islands []*Evolver
+
+	cfg ArchipelagoConfig
+
+	// Draws island seeds and random migration destinations.
+	rng *rand.Rand
+
+	// Number of completed iterations.
+	iteration int
+
+	// Number of migrations so far.
+	migrations int
+
+	// Island with the best form of the most recent iteration.
+	best int
+
+	// Best score and its cost over all islands, as Evolver.
+	topScore   float64
+	topCost    float64
+	improvedAt int
+
+	solved bool
+	stable bool
+
+	// When to stop Run; nil for SolvedAndStable.
+	stop StopCondition
+
+	// Notified of the progress of the archipelago as a whole.
+	observers []Observer
+}
+
+// Create an archipelago for the problem.
+func NewArchipelago(p ProblemInterface, ac ArchipelagoConfig) (*Archipelago, error) {
+	if len(ac.Islands) < 1 {
+		return nil, fmt.Errorf("archipelago: no islands")
+	}
+	if ac.MigrationInterval < 1 {
+		return nil, fmt.Errorf("archipelago: MigrationInterval must be at least 1, got %d", ac.MigrationInterval)
+	}
+	if ac.Migrants < 0 {
+		return nil, fmt.Errorf("archipelago: Migrants must not be negative, got %d", ac.Migrants)
+	}
+	switch ac.Topology {
+	case TOPOLOGYRING, TOPOLOGYFULL, TOPOLOGYRANDOM:
+	default:
+		return nil, fmt.Errorf("archipelago: unknown topology %q", ac.Topology)
+	}
+
+	if ac.Seed == 0 {
+		ac.Seed = time.Now().UnixNano()
+	}
+	ac.Islands = append([]Config(nil), ac.Islands...)
+
+	a := &Archipelago{
+		cfg:      ac,
+		rng:      rand.New(newSource(ac.Seed)),
+		topScore: -math.MaxFloat64,
+	}
+
+	for i := range ac.Islands {
+		c := &ac.Islands[i]
+		if c.Seed == 0 {
+			c.Seed = a.rng.Int63() + 1
+		}
+		if c.Workers == 0 {
+			c.Workers = 1
+		}
+
+		e, err := NewEvolver(p, *c)
+		if err != nil {
+			return nil, fmt.Errorf("archipelago: island %d: %v", i, err)
+		}
+		if i > 0 && !sameOperations(a.islands[0].cfg.InstructionSet, e.cfg.InstructionSet) {
+			return nil, fmt.Errorf("archipelago: island %d has a different instruction set", i)
+		}
+		a.islands = append(a.islands, &e)
+	}
+
+	return a, nil
+}
+
+// The archipelago's settings, with island seeds filled in.
+func (a *Archipelago) Config() ArchipelagoConfig {
+	return a.cfg
+}
+
+// Number of islands.
+func (a *Archipelago) Len() int {
+	return len(a.islands)
+}
+
+// The evolver of island i, e.g. to add observers or a checkpoint directory.
+// It must not be run on its own.
+func (a *Archipelago) Island(i int) *Evolver {
+	return a.islands[i]
+}
+
+// Number of completed iterations.
+func (a *Archipelago) Iteration() int {
+	return a.iteration
+}
+
+// Number of migrations so far.
+func (a *Archipelago) Migrations() int {
+	return a.migrations
+}
+
+// Copy of the best form over all islands of the most recent evaluation.
+func (a *Archipelago) Best() Form {
+	return a.islands[a.best].Best()
+}
+
+// Add an observer to be notified of the progress of the archipelago as a
+// whole.  Events describe the best island.
+func (a *Archipelago) AddObserver(o Observer) {
+	a.observers = append(a.observers, o)
+}
+
+// Set when Run stops; several conditions stop on the first that triggers.
+func (a *Archipelago) StopWhen(conds ...StopCondition) {
+	if len(conds) == 1 {
+		a.stop = conds[0]
+	} else {
+		a.stop = AnyOf(conds...)
+	}
+}
+
+// Evolve every island until a stop condition triggers or ctx is done.  Stop
+// conditions see the archipelago as a whole: the best island's scores, the
+// evaluations of all islands, and solved or stable once any island is.
+func (a *Archipelago) Run(ctx context.Context) (Result, error) {
+	start := time.Now()
+
+	stop := a.stop
+	if stop == nil {
+		stop = SolvedAndStable()
+	}
+
+	for {
+		a.parallel((*Evolver).step)
+		a.bookKeeping()
+		s := a.runState(time.Since(start))
+
+		if err := ctx.Err(); err != nil {
+			return a.result(s, REASONCANCELLED), err
+		}
+		if reason := stop.ShouldStop(s); reason != "" {
+			return a.result(s, reason), nil
+		}
+
+		if a.iteration%a.cfg.MigrationInterval == 0 {
+			a.migrate()
+		}
+		a.parallel((*Evolver).advance)
+	}
+}
+
+// Call fn on every island in its own goroutine.  Islands share no mutable
+// state.
+func (a *Archipelago) parallel(fn func(e *Evolver)) {
+	var wg sync.WaitGroup
+	for _, e := range a.islands {
+		wg.Add(1)
+		go func(e *Evolver) {
+			defer wg.Done()
+			fn(e)
+		}(e)
+	}
+	wg.Wait()
+}
+
+// Find the best island and notify observers, after every island has been
+// evaluated.
+func (a *Archipelago) bookKeeping() {
+	i := a.iteration
+	a.iteration++
+	wasSolved, wasStable := a.solved, a.stable
+	prevScore, prevCost := a.topScore, a.topCost
+
+	bests := make([]Form, len(a.islands))
+	for k, e := range a.islands {
+		bests[k] = e.forms[e.best]
+		a.solved = a.solved || e.solved
+		a.stable = a.stable || e.solvedNStable
+	}
+	a.best = rankOrder(ByAvgScore(bests))[0]
+
+	e := a.islands[a.best]
+	best := bests[a.best]
+	score, cost := best.AvgScore(), best.AvgCost()
+	if score > a.topScore || (score == a.topScore && cost < a.topCost) {
+		a.topScore = score
+		a.topCost = cost
+		a.improvedAt = a.iteration
+	}
+
+	for _, o := range a.observers {
+		o.OnIteration(IterationEvent{
+			Iteration:       i,
+			BestScore:       score,
+			BestCost:        cost,
+			Solved:          a.solved,
+			StableFor:       e.sameSolvedCostCount,
+			Population:      e.populationStats(),
+			Validated:       len(e.validation) > 0,
+			ValidationScore: e.validationScore,
+			Best:            best,
+		})
+		if a.improvedAt == a.iteration {
+			o.OnNewBest(NewBestEvent{Iteration: i, Score: a.topScore, Cost: a.topCost, PreviousScore: prevScore, PreviousCost: prevCost, Best: best})
+		}
+		if a.solved && !wasSolved {
+			o.OnSolved(SolvedEvent{Iteration: i, Cost: cost, Best: best})
+		}
+		if a.stable && !wasStable {
+			o.OnStable(StableEvent{Iteration: i, Cost: cost, StableFor: e.sameSolvedCostCount, Best: best})
+		}
+	}
+}
+
+// Send copies of each island's best forms to other islands, replacing their
+// worst forms.
+func (a *Archipelago) migrate() {
+	if a.cfg.Migrants == 0 || len(a.islands) < 2 {
+		return
+	}
+	a.migrations++
+
+	// Choose every island's emigrants before any arrive.
+	emigrants := make([][]Form, len(a.islands))
+	for k, e := range a.islands {
+		emigrants[k] = e.emigrants(a.cfg.Migrants)
+	}
+
+	arrivals := make([][]Form, len(a.islands))
+	for from := range a.islands {
+		for _, to := range destinations(a.cfg.Topology, from, len(a.islands), a.rng) {
+			arrivals[to] = append(arrivals[to], emigrants[from]...)
+		}
+	}
+
+	for to, e := range a.islands {
+		e.immigrate(arrivals[to])
+	}
+}
+
+// Islands receiving the migrants of island from, of n islands.
+func destinations(topology string, from int, n int, rng *rand.Rand) []int {
+	switch topology {
+	case TOPOLOGYFULL:
+		var to []int
+		for k := 0; k < n; k++ {
+			if k != from {
+				to = append(to, k)
+			}
+		}
+		return to
+	case TOPOLOGYRANDOM:
+		return []int{(from + 1 + rng.Intn(n-1)) % n}
+	}
+	return []int{(from + 1) % n}
+}
+
+// The island's n best evaluated forms.
+func (e *Evolver) emigrants(n int) []Form {
+	var forms []Form
+	order := rankOrder(ByAvgScore(e.forms))
+	for _, i := range order[:minInt(n, len(order))] {
+		forms = append(forms, e.forms[i])
+	}
+	return forms
+}
+
+// Replace the island's worst evaluated forms with copies of migrants.
+// Migrants keep the average score and cost they earned at home so selection
+// can weigh them against the forms they join, but not their scores on the
+// home island's cases, which the island's forms weren't run on.
+func (e *Evolver) immigrate(migrants []Form) {
+	worst := rankOrder(ByAvgScore(e.forms))
+	for k, f := range migrants {
+		if k >= len(worst) {
+			break
+		}
+		e.forms[worst[len(worst)-1-k]] = immigrant(f, e.cfg)
+	}
+}
+
+// Copy of f with the configuration of the island it joins, keeping its
+// statistics other than its case scores.
+func immigrant(f Form, c *Config) Form {
+	m := f.Clone()
+	m.cfg = c
+	m.caseScores = nil
+	m.init()
+	if len(m.instructions) > c.CodeSize {
+		m.instructions = m.instructions[:c.CodeSize]
+	}
+	return m
+}
+
+func (a *Archipelago) runState(elapsed time.Duration) RunState {
+	var evaluations int64
+	for _, e := range a.islands {
+		evaluations += e.evaluations
+	}
+
+	best := a.islands[a.best].forms[a.islands[a.best].best]
+	return RunState{
+		Iteration:        a.iteration,
+		Evaluations:      evaluations,
+		Elapsed:          elapsed,
+		BestScore:        best.AvgScore(),
+		BestCost:         best.AvgCost(),
+		TopScore:         a.topScore,
+		Solved:           a.solved,
+		Stable:           a.stable,
+		SinceImprovement: a.iteration - a.improvedAt,
+	}
+}
+
+func (a *Archipelago) result(s RunState, reason string) Result {
+	r := a.islands[a.best].result(s, reason)
+
+	// Merge the islands' halls of fame.
+	var h *HallOfFame
+	for _, e := range a.islands {
+		if e.hallOfFame == nil {
+			continue
+		}
+		if h == nil {
+			h = NewHallOfFame(e.hallOfFame.Size())
+		}
+		for _, en := range e.hallOfFame.Entries() {
+			h.add(en.Form, en.Generation)
+		}
+	}
+	r.HallOfFame = nil
+	if h != nil {
+		r.HallOfFame = h.Entries()
+	}
+
+	return r
+}
+
+// Do the instruction sets have the same operations in the same order?
+func sameOperations(a, b *InstructionSet) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	for i := 0; i < a.Len(); i++ {
+		opa, _ := a.Lookup(i)
+		opb, _ := b.Lookup(i)
+		if opa.Name != opb.Name {
+			return false
+		}
+	}
+	return true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
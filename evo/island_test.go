@@ -0,0 +1,133 @@
+package evo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testArchipelago(t *testing.T, p ProblemInterface, islands int, forms int, seed int64) *Archipelago {
+	c := DefaultConfig()
+	c.Forms = forms
+	c.StabilityDuration = 5
+	ac := DefaultArchipelagoConfig(c, islands)
+	ac.Seed = seed
+
+	a, err := NewArchipelago(p, ac)
+	require.NoError(t, err)
+	return a
+}
+
+func TestNewArchipelagoValidates(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 10
+
+	bad := []func(ac *ArchipelagoConfig){
+		func(ac *ArchipelagoConfig) { ac.Islands = nil },
+		func(ac *ArchipelagoConfig) { ac.MigrationInterval = 0 },
+		func(ac *ArchipelagoConfig) { ac.Migrants = -1 },
+		func(ac *ArchipelagoConfig) { ac.Topology = "star" },
+		func(ac *ArchipelagoConfig) { ac.Islands[1].Forms = 0 },
+		func(ac *ArchipelagoConfig) {
+			isa := DefaultInstructionSet()
+			isa.MustRegister(Operation{Name: "extra", Exec: (*Form).noop})
+			ac.Islands[1].InstructionSet = isa
+		},
+	}
+	for i, change := range bad {
+		ac := DefaultArchipelagoConfig(c, 2)
+		change(&ac)
+		_, err := NewArchipelago(CopyProblem{}, ac)
+		assert.Error(t, err, "case %d", i)
+	}
+
+	a, err := NewArchipelago(CopyProblem{}, DefaultArchipelagoConfig(c, 3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, a.Len())
+	assert.NotEqual(t, a.Island(0).Config().Seed, a.Island(1).Config().Seed)
+	assert.Equal(t, 1, a.Island(0).Config().Workers)
+}
+
+func TestArchipelagoDestinations(t *testing.T) {
+	a := testArchipelago(t, CopyProblem{}, 4, 10, 1)
+
+	assert.Equal(t, []int{1}, destinations(a.cfg.Topology, 0, 4, a.rng))
+	assert.Equal(t, []int{0}, destinations(a.cfg.Topology, 3, 4, a.rng))
+
+	a.cfg.Topology = TOPOLOGYFULL
+	assert.Equal(t, []int{0, 1, 3}, destinations(a.cfg.Topology, 2, 4, a.rng))
+
+	a.cfg.Topology = TOPOLOGYRANDOM
+	for i := 0; i < 50; i++ {
+		to := destinations(a.cfg.Topology, 2, 4, a.rng)
+		require.Equal(t, 1, len(to))
+		assert.NotEqual(t, 2, to[0])
+	}
+}
+
+func TestArchipelagoMigrate(t *testing.T) {
+	a := testArchipelago(t, CopyProblem{}, 2, 20, 1)
+	a.cfg.Migrants = 2
+	for _, e := range a.islands {
+		for i := range e.forms {
+			e.forms[i] = NewRandomForm(e.cfg, e.rng)
+		}
+	}
+	a.parallel((*Evolver).step)
+
+	src := a.islands[0]
+	order := rankOrder(ByAvgScore(src.forms))
+	want := [][]Instruction{src.forms[order[0]].Instructions(), src.forms[order[1]].Instructions()}
+	wantScore := src.forms[order[0]].AvgScore()
+
+	a.migrate()
+	assert.Equal(t, 1, a.Migrations())
+
+	found := 0
+	for _, f := range a.islands[1].forms {
+		for _, w := range want {
+			if sameProgram(f.instructions, w) {
+				found++
+				assert.True(t, f.cfg == a.islands[1].cfg)
+				assert.True(t, f.runCount > 0)
+				assert.Nil(t, f.caseScores)
+			}
+		}
+	}
+	assert.True(t, found >= 2, "migrants not found on the destination island")
+	assert.Equal(t, 20, len(a.islands[1].forms))
+	assert.Equal(t, wantScore, a.islands[1].forms[rankOrder(ByAvgScore(a.islands[1].forms))[0]].AvgScore())
+}
+
+func TestArchipelagoRun(t *testing.T) {
+	run := func() (*Archipelago, Result) {
+		a := testArchipelago(t, Output1Problem{}, 3, 100, 9)
+		a.Island(1).cfg.Selection = "tournament"
+		a.Island(2).cfg.Selection = "rank"
+		a.cfg.MigrationInterval = 5
+		a.StopWhen(SolvedAndStable(), MaxIterations(500))
+
+		obs := &recordingObserver{}
+		a.AddObserver(obs)
+
+		res, err := a.Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, res.Iterations, len(obs.iterations))
+		return a, res
+	}
+
+	a, res := run()
+	assert.True(t, res.Solved, res.Reason)
+	assert.Equal(t, 300*a.cfg.Islands[0].RaceTrials*res.Iterations, int(res.Evaluations))
+	assert.True(t, a.Migrations() > 0)
+	for _, e := range a.islands {
+		assert.Equal(t, res.Iterations, e.Iteration())
+	}
+
+	// Islands run in parallel but the run is reproducible from the seed.
+	_, again := run()
+	assert.Equal(t, res.Iterations, again.Iterations)
+	assert.Equal(t, res.Best.instructions, again.Best.instructions)
+}
@@ -0,0 +1,175 @@
+package evo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// A Population whose forms are also judged on each test case on its own.
+type CasePopulation interface {
+	Population
+
+	// Score of form i on each case.  Every form is scored on the same cases
+	// in the same order.
+	CaseScores(i int) []float64
+}
+
+// Cases are the trials of the latest evaluation.
+func (f ByAvgScore) CaseScores(i int) []float64 {
+	return f[i].caseScores
+}
+
+// Lexicase selection.  For each parent the cases are shuffled and, case by
+// case, only the candidates with the best score on the case are kept until
+// one is left or the cases run out; the parent is a random survivor.  A form
+// that gets cases right which others get wrong is picked even when its
+// average is poor, so specialists keep breeding where averaging would hide
+// them.  With Epsilon, candidates within the median absolute deviation of
+// the case's scores of the best are kept too (epsilon-lexicase), which suits
+// scores that are rarely exactly equal.  Forms without case scores are
+// judged on their fitness alone.
+type LexicaseSelection struct {
+	Epsilon bool
+}
+
+// Forms with identical case scores, which lexicase can't tell apart.
+type caseGroup struct {
+	members []int
+	scores  []float64
+}
+
+func (s LexicaseSelection) Select(pop Population, n int, rng *rand.Rand) []int {
+	scores := caseScores(pop)
+	cases := len(scores[0])
+
+	var groups []caseGroup
+	index := map[string]int{}
+	for i, v := range scores {
+		key := fmt.Sprint(v)
+		g, ok := index[key]
+		if !ok {
+			g = len(groups)
+			index[key] = g
+			groups = append(groups, caseGroup{scores: v})
+		}
+		groups[g].members = append(groups[g].members, i)
+	}
+
+	epsilon := make([]float64, cases)
+	if s.Epsilon {
+		for c := range epsilon {
+			epsilon[c] = medianAbsoluteDeviation(scores, c)
+		}
+	}
+
+	// Every parent starts with the whole population, so the survivors of
+	// each first case are worked out once.
+	all := make([]int, len(groups))
+	for g := range all {
+		all[g] = g
+	}
+	first := make([][]int, cases)
+	for c := range first {
+		first[c] = bestOnCase(groups, all, c, epsilon[c])
+	}
+
+	parents := make([]int, n)
+	for slot := range parents {
+		order := rng.Perm(cases)
+		candidates := first[order[0]]
+		for _, c := range order[1:] {
+			if len(candidates) == 1 {
+				break
+			}
+			candidates = bestOnCase(groups, candidates, c, epsilon[c])
+		}
+
+		// A random form among the survivors.
+		total := 0
+		for _, g := range candidates {
+			total += len(groups[g].members)
+		}
+		k := rng.Intn(total)
+		for _, g := range candidates {
+			if k < len(groups[g].members) {
+				parents[slot] = groups[g].members[k]
+				break
+			}
+			k -= len(groups[g].members)
+		}
+	}
+	return parents
+}
+
+// The candidate groups within epsilon of the best score on case c.
+func bestOnCase(groups []caseGroup, candidates []int, c int, epsilon float64) []int {
+	best := math.Inf(-1)
+	for _, g := range candidates {
+		best = maxFloat(best, groups[g].scores[c])
+	}
+
+	var kept []int
+	for _, g := range candidates {
+		if groups[g].scores[c] >= best-epsilon {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+// Case scores of every form, cut to the cases all forms with case scores
+// have.  A form without them, such as a migrant just arrived from another
+// island, scores its fitness on every case, and a population without any
+// has its fitness as its only case.
+func caseScores(pop Population) [][]float64 {
+	scores := make([][]float64, pop.Len())
+	cases := math.MaxInt32
+	if cp, ok := pop.(CasePopulation); ok {
+		for i := range scores {
+			scores[i] = cp.CaseScores(i)
+			if len(scores[i]) > 0 {
+				cases = minInt(cases, len(scores[i]))
+			}
+		}
+	}
+	if cases == math.MaxInt32 {
+		cases = 1
+	}
+
+	for i := range scores {
+		if len(scores[i]) == 0 {
+			scores[i] = make([]float64, cases)
+			for c := range scores[i] {
+				scores[i][c] = pop.Fitness(i)
+			}
+		} else {
+			scores[i] = scores[i][:cases]
+		}
+	}
+	return scores
+}
+
+// Median absolute deviation of the scores on case c.
+func medianAbsoluteDeviation(scores [][]float64, c int) float64 {
+	values := make([]float64, len(scores))
+	for i := range scores {
+		values[i] = scores[i][c]
+	}
+	m := median(values)
+	for i := range values {
+		values[i] = math.Abs(values[i] - m)
+	}
+	return median(values)
+}
+
+// Median of values, which are reordered.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
@@ -0,0 +1,109 @@
+package evo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Population with explicit case scores; fitness is their mean.
+type casePop [][]float64
+
+func (p casePop) Len() int                   { return len(p) }
+func (p casePop) Less(i, j int) bool         { return p.Fitness(i) > p.Fitness(j) }
+func (p casePop) CaseScores(i int) []float64 { return p[i] }
+func (p casePop) Fitness(i int) float64 {
+	sum := 0.0
+	for _, v := range p[i] {
+		sum += v
+	}
+	return sum / float64(len(p[i]))
+}
+
+func lexicaseCounts(sel LexicaseSelection, pop Population) []int {
+	counts := make([]int, pop.Len())
+	for _, p := range sel.Select(pop, 6000, testRand()) {
+		counts[p]++
+	}
+	return counts
+}
+
+func TestLexicaseSelection(t *testing.T) {
+	pop := casePop{
+		{0, -10, -10}, // 0: best on case 0 only.
+		{-10, 0, -10}, // 1: best on case 1 only.
+		{-10, -10, 0}, // 2: best on case 2 only.
+		{-1, -1, -1},  // 3: best average, best on no case.
+		{-2, -2, -2},  // 4: dominated by 3.
+	}
+
+	counts := lexicaseCounts(LexicaseSelection{}, pop)
+	for i := 0; i < 3; i++ {
+		assert.InDelta(t, 2000, float64(counts[i]), 200, "%v", counts)
+	}
+	assert.Equal(t, 0, counts[3])
+	assert.Equal(t, 0, counts[4])
+
+	// Forms tied on every case share their picks.
+	counts = lexicaseCounts(LexicaseSelection{}, casePop{{0, -1}, {0, -1}, {-1, -2}})
+	assert.InDelta(t, 3000, float64(counts[0]), 200, "%v", counts)
+	assert.InDelta(t, 3000, float64(counts[1]), 200, "%v", counts)
+	assert.Equal(t, 0, counts[2])
+
+	// Without case scores lexicase picks the fittest.
+	counts = lexicaseCounts(LexicaseSelection{}, fitnessPop{-3, 0, -1})
+	assert.Equal(t, []int{0, 6000, 0}, counts)
+
+	// A form without case scores, such as a migrant, scores its fitness on
+	// every case and doesn't hide the others' cases.
+	c := testConfig()
+	forms := []Form{scoredForm(c, 1, -2, 0), scoredForm(c, 2, -2, 0), scoredForm(c, 3, -1, 0)}
+	forms[0].caseScores = []float64{0, -4}
+	forms[1].caseScores = []float64{-4, 0}
+	assert.Equal(t, [][]float64{{0, -4}, {-4, 0}, {-1, -1}}, caseScores(ByAvgScore(forms)))
+	counts = lexicaseCounts(LexicaseSelection{}, ByAvgScore(forms))
+	assert.Equal(t, 0, counts[2], "%v", counts)
+}
+
+func TestEpsilonLexicaseSelection(t *testing.T) {
+	pop := casePop{
+		{0, -0.5},
+		{-0.5, 0},
+		{-0.1, -0.1}, // Near the best on both cases.
+		{-9, -9},
+		{-10, -10},
+	}
+
+	counts := lexicaseCounts(LexicaseSelection{}, pop)
+	assert.Equal(t, 0, counts[2], "%v", counts)
+
+	// Within the median absolute deviation (0.5) of the best on each case,
+	// form 2 survives every case.
+	counts = lexicaseCounts(LexicaseSelection{Epsilon: true}, pop)
+	assert.True(t, counts[2] > 1500, "%v", counts)
+	assert.Equal(t, 0, counts[3]+counts[4], "%v", counts)
+
+	assert.Equal(t, 0.5, medianAbsoluteDeviation(pop, 0))
+}
+
+func TestEvolverLexicase(t *testing.T) {
+	for _, name := range []string{"lexicase", "epsilon-lexicase"} {
+		c := DefaultConfig()
+		c.Forms = 500
+		c.StabilityDuration = 5
+		c.Seed = 2
+		c.Workers = 1
+		c.Selection = name
+		c.Elites = 1
+
+		e, err := NewEvolver(Copy3Problem{}, c)
+		require.NoError(t, err)
+		e.StopWhen(SolvedAndStable(), MaxIterations(500))
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		assert.True(t, res.Solved, "%s: %s", name, res.Reason)
+		assert.Equal(t, c.RaceTrials, len(res.Best.caseScores))
+	}
+}
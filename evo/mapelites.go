@@ -0,0 +1,451 @@
+package evo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Features a MAP-Elites grid can be built on, see FeatureAxis.
+const FEATURESTEPS = "steps"     // Instructions executed per run.
+const FEATUREOPCODES = "opcodes" // Distinct operations executed.
+const FEATUREMEMORY = "memory"   // Distinct memory cells written.
+const FEATURECOST = "cost"       // AvgCost.
+
+// Names accepted by FeatureAxis.Feature.
+func FeatureNames() []string {
+	return []string{FEATURECOST, FEATUREMEMORY, FEATUREOPCODES, FEATURESTEPS}
+}
+
+// One dimension of a MAP-Elites grid.  Feature values from Min up to Max
+// are split into Bins equal ranges; values outside fall in the first or
+// last bin.
+type FeatureAxis struct {
+	Feature  string
+	Min, Max float64
+	Bins     int
+}
+
+// An axis for the named feature covering every value it can take under c,
+// with at most 10 bins.
+func NewFeatureAxis(name string, c Config) (FeatureAxis, error) {
+	isa := c.instructionSet()
+	switch name {
+	case FEATURESTEPS:
+		return integerAxis(name, c.MaxOps), nil
+	case FEATUREOPCODES:
+		return integerAxis(name, isa.Len()), nil
+	case FEATUREMEMORY:
+		return integerAxis(name, c.MemSize), nil
+	case FEATURECOST:
+		maxCost := INVALIDOPCOST
+		for i := 0; i < isa.Len(); i++ {
+			op, _ := isa.Lookup(i)
+			if op.Cost > maxCost {
+				maxCost = op.Cost
+			}
+		}
+		return FeatureAxis{Feature: name, Max: float64(c.MaxOps * maxCost), Bins: 10}, nil
+	}
+	return FeatureAxis{}, fmt.Errorf("unknown feature %q", name)
+}
+
+// Axis over the counts 0..max.
+func integerAxis(name string, max int) FeatureAxis {
+	return FeatureAxis{Feature: name, Max: float64(max + 1), Bins: minInt(max+1, 10)}
+}
+
+// Bin of value v.
+func (a FeatureAxis) bin(v float64) int {
+	b := int((v - a.Min) / (a.Max - a.Min) * float64(a.Bins))
+	if b < 0 {
+		return 0
+	}
+	if b >= a.Bins {
+		return a.Bins - 1
+	}
+	return b
+}
+
+// Settings for MapElites.
+type MapElitesConfig struct {
+	// Evolution settings.  Forms is the number of children bred from the
+	// grid and evaluated per iteration, and the Elites best forms of the grid
+	// are evaluated again among them.  Selection isn't used.
+	Config Config
+
+	// Axes of the grid.
+	Features []FeatureAxis
+
+	// Probe inputs the features are measured on.
+	Probes int
+}
+
+// A grid over instructions executed and distinct operations used, measured
+// on 10 probes.
+func DefaultMapElitesConfig(c Config) MapElitesConfig {
+	steps, _ := NewFeatureAxis(FEATURESTEPS, c)
+	opcodes, _ := NewFeatureAxis(FEATUREOPCODES, c)
+	return MapElitesConfig{
+		Config:   c,
+		Features: []FeatureAxis{steps, opcodes},
+		Probes:   10,
+	}
+}
+
+// The best form found for a cell of the grid.
+type Elite struct {
+	// Bin on each axis.
+	Cell []int
+
+	// Feature values the form was placed by.
+	Features []float64
+
+	// The form with its statistics summed over every evaluation of its
+	// program in the cell; Score and Cost are their means.
+	Form  Form
+	Score float64
+	Cost  float64
+
+	// Iteration the form was first placed in.
+	Generation int
+}
+
+// MapElites is a quality-diversity search.  Rather than one population
+// converging on a winner it keeps a grid of elites, the best form found for
+// each combination of program features such as instructions executed or
+// operations used.  Each iteration breeds children from elites chosen at
+// random and places each child in its cell when the cell is empty or the
+// child beats the cell's elite.  The grid maps how good a program can be in
+// every region of program space.
+type MapElites struct {
+	// Evaluates each iteration's children and tracks the run.
+	evolver *Evolver
+
+	cfg MapElitesConfig
+
+	// Inputs the features are measured on, drawn on the first placement.
+	probes [][]int
+
+	// Elites by flattened cell index, and the filled cells in the order they
+	// were first filled.  Parents are drawn from filled.
+	cells  map[int]*Elite
+	filled []int
+}
+
+// Create a MAP-Elites search for the problem.
+func NewMapElites(p ProblemInterface, mc MapElitesConfig) (*MapElites, error) {
+	if len(mc.Features) == 0 {
+		return nil, fmt.Errorf("map-elites: no features")
+	}
+	for _, a := range mc.Features {
+		if _, err := NewFeatureAxis(a.Feature, mc.Config); err != nil {
+			return nil, fmt.Errorf("map-elites: %v", err)
+		}
+		if a.Bins < 1 {
+			return nil, fmt.Errorf("map-elites: feature %q: Bins must be at least 1, got %d", a.Feature, a.Bins)
+		}
+		if a.Max <= a.Min {
+			return nil, fmt.Errorf("map-elites: feature %q: Max %g must be above Min %g", a.Feature, a.Max, a.Min)
+		}
+	}
+	if mc.Probes < 1 {
+		return nil, fmt.Errorf("map-elites: Probes must be at least 1, got %d", mc.Probes)
+	}
+
+	e, err := NewEvolver(p, mc.Config)
+	if err != nil {
+		return nil, err
+	}
+	mc.Config = e.Config()
+	mc.Features = append([]FeatureAxis(nil), mc.Features...)
+
+	return &MapElites{evolver: &e, cfg: mc, cells: map[int]*Elite{}}, nil
+}
+
+// The search's settings, with defaults filled in.
+func (m *MapElites) Config() MapElitesConfig {
+	return m.cfg
+}
+
+// Number of completed iterations.
+func (m *MapElites) Iteration() int {
+	return m.evolver.iteration
+}
+
+// Number of cells in the grid.
+func (m *MapElites) Cells() int {
+	n := 1
+	for _, a := range m.cfg.Features {
+		n *= a.Bins
+	}
+	return n
+}
+
+// Number of filled cells.
+func (m *MapElites) Len() int {
+	return len(m.filled)
+}
+
+// The elites of the filled cells, in cell order.
+func (m *MapElites) Grid() []Elite {
+	keys := append([]int(nil), m.filled...)
+	sort.Ints(keys)
+
+	grid := make([]Elite, len(keys))
+	for k, idx := range keys {
+		grid[k] = *m.cells[idx]
+		grid[k].Form = grid[k].Form.Clone()
+	}
+	return grid
+}
+
+// Add an observer to be notified of the progress of each iteration's
+// children.
+func (m *MapElites) AddObserver(o Observer) {
+	m.evolver.AddObserver(o)
+}
+
+// Set when Run stops; several conditions stop on the first that triggers.
+func (m *MapElites) StopWhen(conds ...StopCondition) {
+	m.evolver.StopWhen(conds...)
+}
+
+// See Evolver.SetCases.
+func (m *MapElites) SetCases(training, validation []Case) {
+	m.evolver.SetCases(training, validation)
+}
+
+// Fill the grid until a stop condition triggers or ctx is done.  Stop
+// conditions see the children of each iteration, as Evolver.Run's see its
+// population; the result's best form is the grid's best elite.
+func (m *MapElites) Run(ctx context.Context) (Result, error) {
+	start := time.Now()
+	e := m.evolver
+
+	stop := e.stop
+	if stop == nil {
+		stop = SolvedAndStable()
+	}
+
+	for {
+		e.step()
+		m.place()
+		s := e.runState(time.Since(start))
+
+		if err := ctx.Err(); err != nil {
+			return m.result(s, REASONCANCELLED), err
+		}
+		if reason := stop.ShouldStop(s); reason != "" {
+			return m.result(s, reason), nil
+		}
+
+		m.breed()
+	}
+}
+
+// Measure the evaluated forms' features and place each form that beats its
+// cell's elite.  A form running the elite's program adds its evaluation to
+// the elite's instead, so a lucky evaluation doesn't hold the cell.
+func (m *MapElites) place() {
+	e := m.evolver
+	if m.probes == nil {
+		for i := 0; i < m.cfg.Probes; i++ {
+			m.probes = append(m.probes, e.problem.GenerateInputs(e.rng))
+		}
+	}
+
+	features := make([][]float64, len(e.forms))
+	e.shard(func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			features[i] = e.forms[i].features(m.cfg.Features, m.probes)
+		}
+	})
+
+	for i := range e.forms {
+		f := &e.forms[i]
+		cell := make([]int, len(m.cfg.Features))
+		idx := 0
+		for k, a := range m.cfg.Features {
+			cell[k] = a.bin(features[i][k])
+			idx = idx*a.Bins + cell[k]
+		}
+
+		score, cost := f.AvgScore(), f.AvgCost()
+		if en, ok := m.cells[idx]; ok {
+			if sameCode(e.cfg.instructionSet(), en.Form.instructions, f.instructions) {
+				en.Form.scoreSum += f.scoreSum
+				en.Form.costSum += f.costSum
+				en.Form.runCount += f.runCount
+				en.Score, en.Cost = en.Form.AvgScore(), en.Form.AvgCost()
+				continue
+			}
+			if score < en.Score || (score == en.Score && cost >= en.Cost) {
+				continue
+			}
+		} else {
+			m.filled = append(m.filled, idx)
+		}
+		m.cells[idx] = &Elite{
+			Cell:       cell,
+			Features:   features[i],
+			Form:       f.Clone(),
+			Score:      score,
+			Cost:       cost,
+			Generation: e.iteration - 1,
+		}
+	}
+}
+
+// Replace the evaluated forms with children of elites chosen at random, and
+// the best elites.
+func (m *MapElites) breed() {
+	e := m.evolver
+	elite := func() Form {
+		return m.cells[m.filled[e.rng.Intn(len(m.filled))]].Form
+	}
+	for i := 0; i < len(e.forms); {
+		for _, child := range e.breed(elite(), elite) {
+			if i < len(e.forms) {
+				e.forms[i] = child
+				i++
+			}
+		}
+	}
+
+	if e.cfg.Elites == 0 {
+		return
+	}
+	best := m.ranked()
+	for k := 0; k < e.cfg.Elites && k < len(best); k++ {
+		e.forms[k] = best[k].Form
+		e.forms[k].resetStats()
+	}
+}
+
+// The elites of the filled cells, best score first and cheapest first among
+// equal scores.
+func (m *MapElites) ranked() []Elite {
+	best := m.Grid()
+	sort.SliceStable(best, func(i, j int) bool {
+		if best[i].Score != best[j].Score {
+			return best[i].Score > best[j].Score
+		}
+		return best[i].Cost < best[j].Cost
+	})
+	return best
+}
+
+// The result of the run so far, describing the grid's best elite rather than
+// the latest children.
+func (m *MapElites) result(s RunState, reason string) Result {
+	e := m.evolver
+	r := e.result(s, reason)
+	best := m.ranked()
+	if len(best) == 0 {
+		return r
+	}
+
+	r.Best = best[0].Form
+	r.Score, r.Cost = best[0].Score, best[0].Cost
+	r.Solved = r.Score == 0
+	if r.Validated {
+		r.ValidationScore = e.validate(r.Best)
+		r.Solved = r.Solved && r.ValidationScore == 0
+	}
+	r.Stable = r.Stable && r.Solved
+	return r
+}
+
+// Write the grid as JSON: the axes, the number of cells, and every elite's
+// cell, features, statistics and program (in the Assemble format).
+func (m *MapElites) WriteGrid(w io.Writer) error {
+	type elite struct {
+		Cell       []int
+		Features   []float64
+		Score      float64
+		Cost       float64
+		Generation int
+		Program    string
+	}
+	grid := struct {
+		Features []FeatureAxis
+		Cells    int
+		Elites   []elite
+	}{Features: m.cfg.Features, Cells: m.Cells()}
+
+	for _, en := range m.Grid() {
+		var program strings.Builder
+		if err := Disassemble(en.Form, &program); err != nil {
+			return err
+		}
+		grid.Elites = append(grid.Elites, elite{en.Cell, en.Features, en.Score, en.Cost, en.Generation, program.String()})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(grid)
+}
+
+// Values of the features of the form, run on each probe.  Probe runs don't
+// count towards the form's statistics.
+func (f *Form) features(axes []FeatureAxis, probes [][]int) []float64 {
+	t := &trace{opcodes: map[int]bool{}, written: map[int]bool{}}
+	costSum := f.costSum
+	f.trace = t
+	for _, probe := range probes {
+		f.runCode(&probe)
+	}
+	f.trace = nil
+	f.costSum = costSum
+
+	values := make([]float64, len(axes))
+	for k, a := range axes {
+		switch a.Feature {
+		case FEATURESTEPS:
+			values[k] = float64(t.steps) / float64(len(probes))
+		case FEATUREOPCODES:
+			values[k] = float64(len(t.opcodes))
+		case FEATUREMEMORY:
+			values[k] = float64(len(t.written))
+		case FEATURECOST:
+			values[k] = f.AvgCost()
+		}
+	}
+	return values
+}
+
+// What a form did over one or more runs.
+type trace struct {
+	// Instructions executed, including invalid ones.
+	steps int
+
+	// Valid opcodes executed.
+	opcodes map[int]bool
+
+	// Memory cells whose value an instruction changed.
+	written map[int]bool
+
+	// Memory before the current instruction.
+	mem []int
+}
+
+func (t *trace) before(f *Form, opcode int, valid bool) {
+	t.steps++
+	if valid {
+		t.opcodes[opcode] = true
+	}
+	t.mem = append(t.mem[:0], f.mem...)
+}
+
+func (t *trace) after(f *Form) {
+	for i, v := range f.mem {
+		if v != t.mem[i] {
+			t.written[i] = true
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package evo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormFeatures(t *testing.T) {
+	c := testConfig()
+	f := NewNoopForm(c)
+	f.instructions[0] = NewInstruction(COPYIN, 0, 2)
+	f.instructions[1] = NewInstruction(COPYIN, 1, 5)
+	f.instructions[2] = NewInstruction(COPYRES, 2, 0)
+	f.instructions[3] = NewInstruction(ENDEXEC)
+	f.costSum = 9
+	f.runCount = 3
+
+	axes := []FeatureAxis{{Feature: FEATURESTEPS}, {Feature: FEATUREOPCODES}, {Feature: FEATUREMEMORY}, {Feature: FEATURECOST}}
+	values := f.features(axes, [][]int{{4, 0}, {0, 7}})
+
+	assert.Equal(t, 4.0, values[0])
+	assert.Equal(t, 3.0, values[1]) // copyin, copyres and endexec.
+	assert.Equal(t, 2.0, values[2]) // Cells 2 and 5, each written once.
+	assert.Equal(t, 3.0, values[3])
+
+	// Probes don't count towards the form's statistics.
+	assert.Equal(t, 9, f.costSum)
+	assert.Equal(t, 3, f.runCount)
+	assert.Nil(t, f.trace)
+}
+
+func TestFeatureAxis(t *testing.T) {
+	c := DefaultConfig()
+
+	a, err := NewFeatureAxis(FEATURESTEPS, c)
+	require.NoError(t, err)
+	assert.Equal(t, 10, a.Bins)
+	assert.Equal(t, 0, a.bin(0))
+	assert.Equal(t, 0, a.bin(1))
+	assert.Equal(t, 9, a.bin(float64(c.MaxOps)))
+	assert.Equal(t, 9, a.bin(1000))
+	assert.Equal(t, 0, a.bin(-5))
+
+	a, err = NewFeatureAxis(FEATUREOPCODES, DefaultConfig())
+	require.NoError(t, err)
+	assert.Equal(t, float64(DefaultInstructionSet().Len()+1), a.Max)
+
+	_, err = NewFeatureAxis("colour", c)
+	assert.Error(t, err)
+}
+
+func TestNewMapElitesValidates(t *testing.T) {
+	bad := []func(mc *MapElitesConfig){
+		func(mc *MapElitesConfig) { mc.Features = nil },
+		func(mc *MapElitesConfig) { mc.Features[0].Feature = "colour" },
+		func(mc *MapElitesConfig) { mc.Features[0].Bins = 0 },
+		func(mc *MapElitesConfig) { mc.Features[1].Max = mc.Features[1].Min },
+		func(mc *MapElitesConfig) { mc.Probes = 0 },
+		func(mc *MapElitesConfig) { mc.Config.Forms = 0 },
+	}
+	for i, change := range bad {
+		mc := DefaultMapElitesConfig(DefaultConfig())
+		change(&mc)
+		_, err := NewMapElites(CopyProblem{}, mc)
+		assert.Error(t, err, "case %d", i)
+	}
+}
+
+func TestMapElitesPoolsEvaluations(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 2
+	m, err := NewMapElites(Output1Problem{}, DefaultMapElitesConfig(c))
+	require.NoError(t, err)
+	e := m.evolver
+	e.iteration = 1
+
+	// A lucky evaluation places the program; re-evaluations of it are
+	// averaged in.
+	e.forms = []Form{scoredForm(e.cfg, 5, 0, 4), scoredForm(e.cfg, 5, -4, 2)}
+	m.place()
+	require.Equal(t, 1, m.Len())
+	en := m.Grid()[0]
+	assert.Equal(t, -2.0, en.Score)
+	assert.Equal(t, 3.0, en.Cost)
+	assert.Equal(t, 2, en.Form.runCount)
+
+	e.iteration = 2
+	e.forms = []Form{scoredForm(e.cfg, 5, -7, 3)}
+	m.place()
+	en = m.Grid()[0]
+	assert.Equal(t, -11.0/3, en.Score)
+	assert.Equal(t, 0, en.Generation)
+
+	// Another program in the cell still has to beat the pooled score.
+	e.forms = []Form{scoredForm(e.cfg, 6, -3, 3)}
+	m.place()
+	require.Equal(t, 1, m.Len())
+	assert.Equal(t, -3.0, m.Grid()[0].Score)
+	assert.Equal(t, 1, m.Grid()[0].Form.runCount)
+}
+
+// The result describes the best elite, not the last iteration's children.
+func TestMapElitesResult(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 300
+	c.Seed = 1
+	m, err := NewMapElites(AdditionProblem{}, DefaultMapElitesConfig(c))
+	require.NoError(t, err)
+	m.StopWhen(MaxIterations(30))
+	res, err := m.Run(context.Background())
+	require.NoError(t, err)
+
+	grid := m.Grid()
+	best := grid[0]
+	for _, en := range grid {
+		if en.Score > best.Score || (en.Score == best.Score && en.Cost < best.Cost) {
+			best = en
+		}
+	}
+	assert.Equal(t, best.Score, res.Score)
+	assert.Equal(t, best.Cost, res.Cost)
+	assert.Equal(t, best.Form.Instructions(), res.Best.Instructions())
+	assert.Equal(t, best.Score == 0, res.Solved)
+}
+
+func TestMapElitesRun(t *testing.T) {
+	run := func(workers int) *MapElites {
+		c := DefaultConfig()
+		c.Forms = 200
+		c.StabilityDuration = 5
+		c.Seed = 4
+		c.Workers = workers
+		c.Elites = 1
+
+		mc := DefaultMapElitesConfig(c)
+		memory, err := NewFeatureAxis(FEATUREMEMORY, c)
+		require.NoError(t, err)
+		mc.Features = append(mc.Features, memory)
+
+		m, err := NewMapElites(CopyProblem{}, mc)
+		require.NoError(t, err)
+		m.StopWhen(SolvedAndStable(), MaxIterations(300))
+		res, err := m.Run(context.Background())
+		require.NoError(t, err)
+		assert.True(t, res.Solved, res.Reason)
+		return m
+	}
+
+	m := run(1)
+	assert.Equal(t, 10*10*10, m.Cells())
+	assert.True(t, m.Len() > 10, "%d cells filled", m.Len())
+
+	grid := m.Grid()
+	require.Equal(t, m.Len(), len(grid))
+	solved := false
+	for _, en := range grid {
+		for k, a := range m.Config().Features {
+			assert.Equal(t, en.Cell[k], a.bin(en.Features[k]))
+		}
+		solved = solved || en.Score == 0
+	}
+	assert.True(t, solved)
+
+	// Parallel evaluation fills the same grid.
+	p := run(4)
+	assert.Equal(t, m.Iteration(), p.Iteration())
+	assert.Equal(t, len(grid), p.Len())
+
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteGrid(&buf))
+	var export struct {
+		Features []FeatureAxis
+		Cells    int
+		Elites   []struct {
+			Cell    []int
+			Score   float64
+			Program string
+		}
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &export))
+	assert.Equal(t, 3, len(export.Features))
+	assert.Equal(t, 1000, export.Cells)
+	require.Equal(t, len(grid), len(export.Elites))
+	assert.Equal(t, grid[0].Cell, export.Elites[0].Cell)
+
+	// Programs are written in the Assemble format.
+	f, err := AssembleWithConfig(bytes.NewBufferString(export.Elites[0].Program), testConfig())
+	require.NoError(t, err)
+	var program bytes.Buffer
+	require.NoError(t, Disassemble(f, &program))
+	assert.Equal(t, export.Elites[0].Program, program.String())
+}
@@ -0,0 +1,223 @@
+package evo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Population members each form's novelty is measured against, besides the
+// archive.  Bounds the cost of novelty in large populations.
+const NOVELTYSAMPLE = 200
+
+// Novelty search.  A form's behavior is its outputs on a fixed set of probe
+// inputs, and its novelty the mean distance from its behavior to the
+// NoveltyK nearest behaviors of the population and an archive of past novel
+// behaviors.  Selection then ranks forms on novelty blended with score (see
+// Config.Novelty) rather than on score alone, so forms doing something new
+// keep breeding while the score landscape is flat or deceptive.
+
+// Outputs of the form on each probe, concatenated.  Probe runs don't count
+// towards the form's statistics.
+func (f *Form) behavior(probes [][]int) []float64 {
+	costSum := f.costSum
+
+	var b []float64
+	for _, probe := range probes {
+		f.runCode(&probe)
+		for _, v := range f.output {
+			b = append(b, float64(v))
+		}
+	}
+
+	f.costSum = costSum
+	return b
+}
+
+// Measure every form's novelty and archive the most novel behaviors.
+func (e *Evolver) updateNovelty() {
+	if e.probes == nil {
+		for i := 0; i < e.cfg.NoveltyProbes; i++ {
+			e.probes = append(e.probes, e.problem.GenerateInputs(e.rng))
+		}
+	}
+
+	behaviors := make([][]float64, len(e.forms))
+	e.shard(func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			behaviors[i] = e.forms[i].behavior(e.probes)
+		}
+	})
+
+	// Forms are compared with the archive and a sample of the population.
+	refs := append([][]float64(nil), e.noveltyArchive...)
+	sample := len(e.forms)
+	if sample > NOVELTYSAMPLE {
+		sample = NOVELTYSAMPLE
+	}
+	sampled := make([]int, sample)
+	for k := range sampled {
+		if sample == len(e.forms) {
+			sampled[k] = k
+		} else {
+			sampled[k] = e.rng.Intn(len(e.forms))
+		}
+		refs = append(refs, behaviors[sampled[k]])
+	}
+
+	// Many forms behave alike, so each distinct behavior is measured once.
+	distinct := map[string]int{}
+	var first []int
+	of := make([]int, len(e.forms))
+	for i, b := range behaviors {
+		key := fmt.Sprint(b)
+		d, ok := distinct[key]
+		if !ok {
+			d = len(first)
+			distinct[key] = d
+			first = append(first, i)
+		}
+		of[i] = d
+	}
+
+	novelty := make([]float64, len(first))
+	e.shardN(len(first), func(lo, hi int) {
+		for d := lo; d < hi; d++ {
+			novelty[d] = kNearestDistance(behaviors[first[d]], refs, e.cfg.NoveltyK)
+		}
+	})
+	e.novelty = make([]float64, len(e.forms))
+	for i := range e.novelty {
+		e.novelty[i] = novelty[of[i]]
+	}
+
+	// Archive the most novel behaviors of this iteration, oldest out first.
+	if e.cfg.NoveltyArchive > 0 {
+		order := make([]int, len(e.forms))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return e.novelty[order[a]] > e.novelty[order[b]]
+		})
+
+		add := len(e.forms) / 100
+		if add < 1 {
+			add = 1
+		}
+		for _, i := range order[:add] {
+			e.noveltyArchive = append(e.noveltyArchive, behaviors[i])
+		}
+		if over := len(e.noveltyArchive) - e.cfg.NoveltyArchive; over > 0 {
+			e.noveltyArchive = append([][]float64(nil), e.noveltyArchive[over:]...)
+		}
+	}
+}
+
+// Mean Euclidean distance from b to its k nearest refs.  A behavior matching
+// b exactly, such as b itself, counts as a neighbour at distance 0.
+func kNearestDistance(b []float64, refs [][]float64, k int) float64 {
+	if len(refs) == 0 {
+		return 0
+	}
+
+	distances := make([]float64, len(refs))
+	for r, ref := range refs {
+		sum := 0.0
+		for d := range b {
+			diff := b[d] - ref[d]
+			sum += diff * diff
+		}
+		distances[r] = math.Sqrt(sum)
+	}
+	sort.Float64s(distances)
+
+	if k > len(distances) {
+		k = len(distances)
+	}
+	total := 0.0
+	for _, d := range distances[:k] {
+		total += d
+	}
+	return total / float64(k)
+}
+
+// Forms ranked on a blend of score and novelty, each scaled to 0..1 over the
+// population.  Lexicase and NSGA-II see novelty as one more case or
+// objective instead, after the forms' own.
+type noveltyPopulation struct {
+	forms   ByAvgScore
+	novelty []float64
+	fitness []float64
+}
+
+func (p noveltyPopulation) Len() int {
+	return len(p.fitness)
+}
+
+func (p noveltyPopulation) Less(i, j int) bool {
+	return p.fitness[i] > p.fitness[j]
+}
+
+func (p noveltyPopulation) Fitness(i int) float64 {
+	return p.fitness[i]
+}
+
+// Case scores followed by novelty.  A form without case scores has none.
+func (p noveltyPopulation) CaseScores(i int) []float64 {
+	cases := p.forms.CaseScores(i)
+	if len(cases) == 0 {
+		return nil
+	}
+	return append(append([]float64(nil), cases...), p.novelty[i])
+}
+
+// Objectives followed by novelty.
+func (p noveltyPopulation) Objectives(i int) []float64 {
+	return append(p.forms.Objectives(i), p.novelty[i])
+}
+
+func newNoveltyPopulation(forms []Form, novelty []float64, weight float64) noveltyPopulation {
+	scores := make([]float64, len(forms))
+	for i := range forms {
+		scores[i] = forms[i].AvgScore()
+	}
+	scores = normalize(scores)
+	novelty = normalize(novelty)
+
+	p := noveltyPopulation{forms: forms, novelty: novelty, fitness: make([]float64, len(forms))}
+	for i := range p.fitness {
+		p.fitness[i] = (1-weight)*scores[i] + weight*novelty[i]
+	}
+	return p
+}
+
+// Values scaled linearly to 0..1; all 0 when they're equal.
+func normalize(values []float64) []float64 {
+	out := make([]float64, len(values))
+	if len(values) == 0 {
+		return out
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = minFloat(lo, v)
+		hi = maxFloat(hi, v)
+	}
+	if hi == lo {
+		return out
+	}
+	for i, v := range values {
+		out[i] = (v - lo) / (hi - lo)
+	}
+	return out
+}
+
+// The evaluated forms as selection sees them: by score, or by score blended
+// with novelty in novelty search.
+func (e *Evolver) population() Population {
+	if e.cfg.Novelty > 0 && len(e.novelty) == len(e.forms) {
+		return newNoveltyPopulation(e.forms, e.novelty, e.cfg.Novelty)
+	}
+	return ByAvgScore(e.forms)
+}
@@ -0,0 +1,118 @@
+package evo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBehavior(t *testing.T) {
+	c := testConfig()
+	f := NewNoopForm(c)
+	f.instructions[0] = NewInstruction(COPYIN, 0, 0)
+	f.instructions[1] = NewInstruction(COPYRES, 0, 1)
+	f.costSum = 7
+	f.runCount = 1
+
+	b := f.behavior([][]int{{3}, {-2}})
+	require.Equal(t, 2*c.IOSize, len(b))
+	assert.Equal(t, 3.0, b[1])
+	assert.Equal(t, -2.0, b[c.IOSize+1])
+
+	// Probes don't count towards the form's statistics.
+	assert.Equal(t, 7, f.costSum)
+	assert.Equal(t, 1, f.runCount)
+}
+
+func TestKNearestDistance(t *testing.T) {
+	refs := [][]float64{{0, 0}, {3, 4}, {6, 8}, {30, 40}}
+	assert.Equal(t, 0.0, kNearestDistance([]float64{0, 0}, refs, 1))
+	assert.Equal(t, 2.5, kNearestDistance([]float64{0, 0}, refs, 2))
+	assert.Equal(t, 5.0, kNearestDistance([]float64{0, 0}, refs, 3))
+	assert.InDelta(t, 10.0/3, kNearestDistance([]float64{3, 4}, refs[:3], 10), 1e-9)
+	assert.Equal(t, 0.0, kNearestDistance([]float64{3, 4}, nil, 10))
+}
+
+func TestNoveltyPopulation(t *testing.T) {
+	c := testConfig()
+	forms := []Form{scoredForm(c, 1, 0, 1), scoredForm(c, 2, -10, 1), scoredForm(c, 3, -5, 1)}
+	novelty := []float64{0, 4, 2}
+
+	// Score alone.
+	p := newNoveltyPopulation(forms, novelty, 0)
+	assert.Equal(t, []int{0, 2, 1}, rankOrder(p))
+
+	// Novelty alone.
+	p = newNoveltyPopulation(forms, novelty, 1)
+	assert.Equal(t, []int{1, 2, 0}, rankOrder(p))
+	assert.Equal(t, 1.0, p.Fitness(1))
+
+	// An even blend ties the extremes and favours the middling form.
+	p = newNoveltyPopulation(forms, novelty, 0.5)
+	assert.Equal(t, 0.5, p.Fitness(0))
+	assert.Equal(t, 0.5, p.Fitness(1))
+	assert.Equal(t, 0.5, p.Fitness(2))
+
+	assert.Equal(t, []float64{0, 0}, normalize([]float64{3, 3}))
+
+	// Lexicase and NSGA-II get novelty as one more case and objective.
+	forms[0].caseScores = []float64{0, 0}
+	forms[1].caseScores = []float64{-10, -10}
+	p = newNoveltyPopulation(forms, novelty, 0.5)
+	assert.Equal(t, []float64{0, 0, 0}, p.CaseScores(0))
+	assert.Equal(t, []float64{-10, -10, 1}, p.CaseScores(1))
+	assert.Nil(t, p.CaseScores(2))
+	assert.Equal(t, []float64{-10, -1, -1, 1}, p.Objectives(1))
+
+	// The novel form isn't dominated by the better scoring one, and is
+	// picked by lexicase when novelty is the first case.
+	assert.Equal(t, []int{0, 0, 0}, nsga2Rank(p).rank)
+	counts := lexicaseCounts(LexicaseSelection{}, p)
+	assert.True(t, counts[1] > 0, "%v", counts)
+}
+
+func TestEvolverNovelty(t *testing.T) {
+	run := func(workers int) Evolver {
+		c := DefaultConfig()
+		c.Forms = 300
+		c.StabilityDuration = 5
+		c.Seed = 8
+		c.Workers = workers
+		c.Novelty = 0.5
+		c.NoveltyArchive = 10
+		c.Selection = "tournament"
+
+		e, err := NewEvolver(CopyProblem{}, c)
+		require.NoError(t, err)
+		e.StopWhen(SolvedAndStable(), MaxIterations(300))
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		assert.True(t, res.Solved, res.Reason)
+		return e
+	}
+
+	e := run(1)
+	assert.Equal(t, 10, len(e.probes))
+	assert.Equal(t, 10, len(e.noveltyArchive))
+	assert.Equal(t, 300, len(e.novelty))
+
+	// Parallel evaluation makes the same decisions.
+	p := run(4)
+	assert.Equal(t, e.Iteration(), p.Iteration())
+	assert.Equal(t, e.noveltyArchive, p.noveltyArchive)
+
+	// The probes and archive survive a checkpoint.
+	var buf bytes.Buffer
+	require.NoError(t, e.Checkpoint(&buf))
+	r, err := LoadEvolver(&buf, CopyProblem{})
+	require.NoError(t, err)
+	assert.Equal(t, e.probes, r.probes)
+	assert.Equal(t, e.noveltyArchive, r.noveltyArchive)
+
+	c := DefaultConfig()
+	c.Novelty = 1.5
+	assert.Error(t, c.Validate())
+}
@@ -0,0 +1,263 @@
+package evo
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Name of NSGA-II selection for Config.Selection.
+const NSGA2SELECTION = "nsga2"
+
+// A Population whose forms are judged on several objectives rather than
+// one fitness.  Every objective is maximised.
+type MultiObjectivePopulation interface {
+	Population
+
+	// Objective values of form i, the same number for every form.
+	Objectives(i int) []float64
+}
+
+// Objectives are AvgScore, AvgCost and program length, counting only the
+// instructions that aren't noops; lower cost and shorter programs are
+// better, so those two are negated.
+func (f ByAvgScore) Objectives(i int) []float64 {
+	return []float64{f[i].AvgScore(), -f[i].AvgCost(), -float64(f[i].length())}
+}
+
+// NSGA-II selection.  Forms are ranked by Pareto dominance into fronts, and
+// within a front by crowding distance, which favours forms in sparse parts
+// of the front.  Parents are picked by binary tournament on (front, crowding)
+// and the best half of the population by the same order survives unchanged,
+// so good tradeoffs between the objectives aren't lost.  Sorting is
+// quadratic in the population size and done once per generation.
+type NSGA2Selection struct{}
+
+func (s NSGA2Selection) Select(pop Population, n int, rng *rand.Rand) []int {
+	return nsga2Rank(pop).Select(pop, n, rng)
+}
+
+// The best half of the population by front then crowding distance.
+func (s NSGA2Selection) Survivors(pop Population) []int {
+	return nsga2Rank(pop).Survivors(pop)
+}
+
+// The ranking of pop, which selects survivors and parents without sorting
+// again.
+func (s NSGA2Selection) prepare(pop Population) Selection {
+	return nsga2Rank(pop)
+}
+
+// Front number (0 is non-dominated) and crowding distance of every form of
+// one population, which it selects from.
+type nsga2Ranking struct {
+	rank     []int
+	crowding []float64
+}
+
+func (r nsga2Ranking) Select(pop Population, n int, rng *rand.Rand) []int {
+	parents := make([]int, n)
+	for slot := range parents {
+		a, b := rng.Intn(len(r.rank)), rng.Intn(len(r.rank))
+		if r.better(b, a) {
+			a = b
+		}
+		parents[slot] = a
+	}
+	return parents
+}
+
+func (r nsga2Ranking) Survivors(pop Population) []int {
+	order := make([]int, len(r.rank))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return r.better(order[a], order[b])
+	})
+	return order[:len(order)/2]
+}
+
+// Is form i on an earlier front than form j, or less crowded on the same
+// one?
+func (r nsga2Ranking) better(i, j int) bool {
+	if r.rank[i] != r.rank[j] {
+		return r.rank[i] < r.rank[j]
+	}
+	return r.crowding[i] > r.crowding[j]
+}
+
+// Sort pop into fronts and measure the crowding within each.
+func nsga2Rank(pop Population) nsga2Ranking {
+	objectives := make([][]float64, pop.Len())
+	for i := range objectives {
+		if mo, ok := pop.(MultiObjectivePopulation); ok {
+			objectives[i] = mo.Objectives(i)
+		} else {
+			objectives[i] = []float64{pop.Fitness(i)}
+		}
+	}
+
+	rank := make([]int, pop.Len())
+	crowding := make([]float64, pop.Len())
+	for r, front := range paretoFronts(objectives) {
+		for _, i := range front {
+			rank[i] = r
+		}
+		for k, d := range crowdingDistances(objectives, front) {
+			crowding[front[k]] = d
+		}
+	}
+	return nsga2Ranking{rank, crowding}
+}
+
+// Is a at least as good as b in every objective and better in one?
+func dominates(a, b []float64) bool {
+	better := false
+	for k := range a {
+		if a[k] < b[k] {
+			return false
+		}
+		if a[k] > b[k] {
+			better = true
+		}
+	}
+	return better
+}
+
+// Indexes grouped into successive non-dominated fronts.  Only the number of
+// forms dominating each form is kept, so memory is linear; as a front is
+// peeled off its members are compared again with the forms left, which
+// compares each pair at most twice in all.
+func paretoFronts(objectives [][]float64) [][]int {
+	dominatedBy := make([]int, len(objectives))
+	for i := range objectives {
+		for j := i + 1; j < len(objectives); j++ {
+			if dominates(objectives[i], objectives[j]) {
+				dominatedBy[j]++
+			} else if dominates(objectives[j], objectives[i]) {
+				dominatedBy[i]++
+			}
+		}
+	}
+
+	var front []int
+	for i := range objectives {
+		if dominatedBy[i] == 0 {
+			front = append(front, i)
+		}
+	}
+
+	ranked := make([]bool, len(objectives))
+	var fronts [][]int
+	for len(front) > 0 {
+		fronts = append(fronts, front)
+		for _, i := range front {
+			ranked[i] = true
+		}
+
+		var next []int
+		for j := range objectives {
+			if ranked[j] {
+				continue
+			}
+			for _, i := range front {
+				if dominates(objectives[i], objectives[j]) {
+					dominatedBy[j]--
+				}
+			}
+			if dominatedBy[j] == 0 {
+				next = append(next, j)
+			}
+		}
+		front = next
+	}
+	return fronts
+}
+
+// Crowding distance of each member of front: the size of the box around it
+// bounded by its neighbours on every objective.  The extremes of each
+// objective are infinitely far.
+func crowdingDistances(objectives [][]float64, front []int) []float64 {
+	distance := make([]float64, len(front))
+	if len(front) == 0 {
+		return distance
+	}
+
+	order := make([]int, len(front))
+	for m := range objectives[front[0]] {
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return objectives[front[order[a]]][m] < objectives[front[order[b]]][m]
+		})
+
+		lo := objectives[front[order[0]]][m]
+		hi := objectives[front[order[len(order)-1]]][m]
+		distance[order[0]] = math.Inf(1)
+		distance[order[len(order)-1]] = math.Inf(1)
+		if hi == lo {
+			continue
+		}
+		for k := 1; k < len(order)-1; k++ {
+			gap := objectives[front[order[k+1]]][m] - objectives[front[order[k-1]]][m]
+			distance[order[k]] += gap / (hi - lo)
+		}
+	}
+	return distance
+}
+
+// A form on a Pareto front.
+type ParetoPoint struct {
+	Form  Form
+	Score float64
+	Cost  float64
+
+	// Instructions that aren't noops.
+	Length int
+}
+
+// The evaluated forms not dominated on (AvgScore, AvgCost, length), one per
+// distinct point, best score first.  Length counts the instructions that
+// aren't noops.
+func ParetoFront(forms []Form) []ParetoPoint {
+	var evaluated []Form
+	for i := range forms {
+		if forms[i].runCount > 0 {
+			evaluated = append(evaluated, forms[i])
+		}
+	}
+	if len(evaluated) == 0 {
+		return nil
+	}
+
+	pop := ByAvgScore(evaluated)
+	objectives := make([][]float64, len(evaluated))
+	for i := range objectives {
+		objectives[i] = pop.Objectives(i)
+	}
+
+	var front []ParetoPoint
+	for _, i := range paretoFronts(objectives)[0] {
+		f := &evaluated[i]
+		duplicate := false
+		for _, p := range front {
+			if p.Score == f.AvgScore() && p.Cost == f.AvgCost() && p.Length == f.length() {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			front = append(front, ParetoPoint{f.Clone(), f.AvgScore(), f.AvgCost(), f.length()})
+		}
+	}
+
+	sort.SliceStable(front, func(i, j int) bool {
+		if front[i].Score != front[j].Score {
+			return front[i].Score > front[j].Score
+		}
+		return front[i].Cost < front[j].Cost
+	})
+	return front
+}
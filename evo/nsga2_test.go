@@ -0,0 +1,134 @@
+package evo
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Population with explicit objective values.
+type objectivePop [][]float64
+
+func (p objectivePop) Len() int                   { return len(p) }
+func (p objectivePop) Less(i, j int) bool         { return p[i][0] > p[j][0] }
+func (p objectivePop) Fitness(i int) float64      { return p[i][0] }
+func (p objectivePop) Objectives(i int) []float64 { return p[i] }
+
+func TestDominates(t *testing.T) {
+	assert.True(t, dominates([]float64{1, 1}, []float64{0, 1}))
+	assert.False(t, dominates([]float64{1, 1}, []float64{1, 1}))
+	assert.False(t, dominates([]float64{1, 0}, []float64{0, 1}))
+}
+
+func TestParetoFronts(t *testing.T) {
+	objectives := [][]float64{
+		{0, 0}, // 0: dominated by 1, 2 and 3
+		{3, 1}, // 1: front 0
+		{1, 3}, // 2: front 0
+		{2, 2}, // 3: front 0
+		{1, 1}, // 4: front 1 (dominated by 3)
+		{-1, -1},
+	}
+	fronts := paretoFronts(objectives)
+	require.Equal(t, 4, len(fronts))
+	assert.Equal(t, []int{1, 2, 3}, fronts[0])
+	assert.Equal(t, []int{4}, fronts[1])
+	assert.Equal(t, []int{0}, fronts[2])
+	assert.Equal(t, []int{5}, fronts[3])
+
+	d := crowdingDistances(objectives, fronts[0])
+	assert.True(t, math.IsInf(d[0], 1))
+	assert.True(t, math.IsInf(d[1], 1))
+	assert.Equal(t, 2.0, d[2]) // (3-1)/(3-1) on both objectives.
+}
+
+func TestNSGA2Selection(t *testing.T) {
+	pop := objectivePop{{0, 0}, {3, 1}, {1, 3}, {2, 2}, {1, 1}, {-1, -1}}
+
+	counts := make([]int, len(pop))
+	for _, p := range (NSGA2Selection{}).Select(pop, 6000, testRand()) {
+		counts[p]++
+	}
+	assert.True(t, counts[1] > counts[4], "%v", counts)
+	assert.True(t, counts[4] > counts[0], "%v", counts)
+	assert.True(t, counts[0] > counts[5], "%v", counts)
+
+	// Extremes of the first front survive before its crowded middle.
+	assert.Equal(t, []int{1, 2, 3}, NSGA2Selection{}.Survivors(pop))
+
+	// A ranking prepared once selects the same way.
+	ranked := NSGA2Selection{}.prepare(pop).(SurvivorSelection)
+	assert.Equal(t, []int{1, 2, 3}, ranked.Survivors(pop))
+	assert.Equal(t, NSGA2Selection{}.Select(pop, 20, testRand()), ranked.Select(pop, 20, testRand()))
+}
+
+func TestParetoFront(t *testing.T) {
+	c := testConfig()
+
+	exact := scoredForm(c, 1, 0, 30)
+	cheap := scoredForm(c, 2, -4, 10)
+	short := scoredForm(c, 3, -6, 20)
+	dominated := scoredForm(c, 4, -5, 40)
+	for _, f := range []*Form{&exact, &cheap, &dominated} {
+		f.instructions[1] = NewInstruction(SETVAL, 1, 1)
+	}
+
+	front := ParetoFront([]Form{dominated, cheap, exact, exact.Clone(), short, NewNoopForm(c)})
+	require.Equal(t, 3, len(front))
+	assert.Equal(t, 0.0, front[0].Score)
+	assert.Equal(t, 30.0, front[0].Cost)
+	assert.Equal(t, -4.0, front[1].Score)
+	assert.Equal(t, -6.0, front[2].Score)
+	assert.Equal(t, 1, front[2].Length)
+}
+
+func TestParetoFrontEffectiveLength(t *testing.T) {
+	c := testConfig()
+
+	// Programs are all CodeSize long; only the instructions that aren't
+	// noops count.
+	short := scoredForm(c, 1, -2, 10)
+	long := scoredForm(c, 1, -2, 10)
+	long.instructions[5] = NewInstruction(SETVAL, 1, 1)
+	require.Equal(t, len(short.instructions), len(long.instructions))
+
+	pop := ByAvgScore{short, long}
+	fronts := paretoFronts([][]float64{pop.Objectives(0), pop.Objectives(1)})
+	assert.Equal(t, [][]int{{0}, {1}}, fronts)
+
+	front := ParetoFront([]Form{long, short})
+	require.Equal(t, 1, len(front))
+	assert.Equal(t, 1, front[0].Length)
+}
+
+func TestEvolverNSGA2(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 200
+	c.StabilityDuration = 5
+	c.Seed = 3
+	c.Workers = 1
+	c.Selection = NSGA2SELECTION
+
+	e, err := NewEvolver(Output1Problem{}, c)
+	require.NoError(t, err)
+	e.StopWhen(SolvedAndStable(), MaxIterations(300))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, res.Solved, res.Reason)
+	assert.Equal(t, 200, len(e.forms))
+	require.True(t, len(res.ParetoFront) > 0)
+	assert.Equal(t, 0.0, res.ParetoFront[0].Score)
+
+	// The front is mutually non-dominated.
+	for _, a := range res.ParetoFront {
+		for _, b := range res.ParetoFront {
+			pa := []float64{a.Score, -a.Cost, -float64(a.Length)}
+			pb := []float64{b.Score, -b.Cost, -float64(b.Length)}
+			assert.False(t, dominates(pa, pb))
+		}
+	}
+}
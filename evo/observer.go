@@ -0,0 +1,202 @@
+package evo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// An Observer is notified of an Evolver's progress.  Methods are called from
+// the evolution loop; slow observers slow the run.  Events carry copies or
+// read-only views of forms that must not be modified.
+type Observer interface {
+	// After every iteration.
+	OnIteration(IterationEvent)
+
+	// When the best score (or the cost at the best score) improves.
+	OnNewBest(NewBestEvent)
+
+	// The first iteration a form scores 0.0.
+	OnSolved(SolvedEvent)
+
+	// When the solution's cost has held for StabilityDuration iterations.
+	OnStable(StableEvent)
+}
+
+// Summary of a population's scores after an evaluation.
+type PopulationStats struct {
+	Forms       int
+	MeanScore   float64
+	WorstScore  float64
+	MeanCost    float64
+	SolvedForms int // Forms scoring 0.0.
+}
+
+type IterationEvent struct {
+	Iteration  int
+	BestScore  float64
+	BestCost   float64
+	Solved     bool
+	StableFor  int // Iterations the solved cost has held.
+	Population PopulationStats
+
+	// Is there a validation set, and the best form's mean score on it.
+	Validated       bool
+	ValidationScore float64
+
+	Best Form `json:"-"`
+}
+
+type NewBestEvent struct {
+	Iteration     int
+	Score         float64
+	Cost          float64
+	PreviousScore float64
+	PreviousCost  float64
+	Best          Form `json:"-"`
+}
+
+type SolvedEvent struct {
+	Iteration int
+	Cost      float64
+	Best      Form `json:"-"`
+}
+
+type StableEvent struct {
+	Iteration int
+	Cost      float64
+	StableFor int
+	Best      Form `json:"-"`
+}
+
+// NopObserver ignores every event.  Embed it to implement only some methods.
+type NopObserver struct{}
+
+func (NopObserver) OnIteration(IterationEvent) {}
+func (NopObserver) OnNewBest(NewBestEvent)     {}
+func (NopObserver) OnSolved(SolvedEvent)       {}
+func (NopObserver) OnStable(StableEvent)       {}
+
+func (e *Evolver) populationStats() PopulationStats {
+	s := PopulationStats{Forms: len(e.forms)}
+	if len(e.forms) == 0 {
+		return s
+	}
+
+	s.WorstScore = e.forms[0].AvgScore()
+	for i := range e.forms {
+		score := e.forms[i].AvgScore()
+		s.MeanScore += score
+		s.MeanCost += e.forms[i].AvgCost()
+		if score < s.WorstScore {
+			s.WorstScore = score
+		}
+		if score == 0.0 {
+			s.SolvedForms++
+		}
+	}
+	s.MeanScore /= float64(len(e.forms))
+	s.MeanCost /= float64(len(e.forms))
+
+	return s
+}
+
+// Reports progress as human readable text, printing the best form every
+// Every iterations.  This is what RunAndReport prints to stdout.
+type StdoutObserver struct {
+	w     io.Writer
+	Every int
+}
+
+func NewStdoutObserver(w io.Writer) *StdoutObserver {
+	return &StdoutObserver{w: w, Every: 10}
+}
+
+func (o *StdoutObserver) OnIteration(ev IterationEvent) {
+	if o.Every > 0 && ev.Iteration%o.Every == 0 {
+		fmt.Fprintln(o.w, "Best form:")
+		fmt.Fprintln(o.w, ev.Best.Description())
+
+		if ev.Solved {
+			fmt.Fprintln(o.w, "--Solved--  Stable for", ev.StableFor, "iterations")
+		}
+	}
+
+	if ev.Validated {
+		fmt.Fprintln(o.w, "Iteration ", ev.Iteration, " complete.  runTopScore : ", ev.BestScore, "cost:", ev.BestCost, " mean score:", ev.Population.MeanScore, " validation score:", ev.ValidationScore)
+		return
+	}
+	fmt.Fprintln(o.w, "Iteration ", ev.Iteration, " complete.  runTopScore : ", ev.BestScore, "cost:", ev.BestCost, " mean score:", ev.Population.MeanScore)
+}
+
+func (o *StdoutObserver) OnNewBest(ev NewBestEvent) {}
+
+func (o *StdoutObserver) OnSolved(ev SolvedEvent) {
+	fmt.Fprintln(o.w, "Solved at iteration", ev.Iteration, "cost:", ev.Cost)
+}
+
+func (o *StdoutObserver) OnStable(ev StableEvent) {
+	fmt.Fprintln(o.w, "Stable solution!")
+}
+
+// Writes every event as a line of JSON:
+//
+//	{"event":"iteration","data":{"Iteration":3,"BestScore":-12.5,...}}
+//
+// new_best, solved and stable events include the best form's program in the
+// Assemble format as "program".
+type JSONLinesObserver struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewJSONLinesObserver(w io.Writer) *JSONLinesObserver {
+	return &JSONLinesObserver{enc: json.NewEncoder(w)}
+}
+
+// First error encountered writing events, if any.
+func (o *JSONLinesObserver) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+func (o *JSONLinesObserver) write(event string, data interface{}, best *Form) {
+	line := struct {
+		Event   string      `json:"event"`
+		Data    interface{} `json:"data"`
+		Program string      `json:"program,omitempty"`
+	}{Event: event, Data: data}
+
+	if best != nil {
+		var b strings.Builder
+		if Disassemble(*best, &b) == nil {
+			line.Program = b.String()
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.enc.Encode(line); err != nil && o.err == nil {
+		o.err = err
+	}
+}
+
+func (o *JSONLinesObserver) OnIteration(ev IterationEvent) {
+	o.write("iteration", ev, nil)
+}
+
+func (o *JSONLinesObserver) OnNewBest(ev NewBestEvent) {
+	o.write("new_best", ev, &ev.Best)
+}
+
+func (o *JSONLinesObserver) OnSolved(ev SolvedEvent) {
+	o.write("solved", ev, &ev.Best)
+}
+
+func (o *JSONLinesObserver) OnStable(ev StableEvent) {
+	o.write("stable", ev, &ev.Best)
+}
@@ -0,0 +1,78 @@
+package evo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	iterations []IterationEvent
+	newBests   []NewBestEvent
+	solved     []SolvedEvent
+	stable     []StableEvent
+}
+
+func (o *recordingObserver) OnIteration(ev IterationEvent) { o.iterations = append(o.iterations, ev) }
+func (o *recordingObserver) OnNewBest(ev NewBestEvent)     { o.newBests = append(o.newBests, ev) }
+func (o *recordingObserver) OnSolved(ev SolvedEvent)       { o.solved = append(o.solved, ev) }
+func (o *recordingObserver) OnStable(ev StableEvent)       { o.stable = append(o.stable, ev) }
+
+func TestObserverEvents(t *testing.T) {
+	c := DefaultConfig()
+	c.Forms = 200
+	c.StabilityDuration = 5
+	c.Seed = 1
+	e, err := NewEvolver(Output1Problem{}, c)
+	require.NoError(t, err)
+
+	rec := &recordingObserver{}
+	var jsonl bytes.Buffer
+	e.AddObserver(rec)
+	e.AddObserver(NewJSONLinesObserver(&jsonl))
+	e.StopWhen(SolvedAndStable(), MaxIterations(300))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, res.Stable, "output1 should be solved quickly")
+
+	assert.Equal(t, e.iteration, len(rec.iterations))
+	assert.Equal(t, 1, len(rec.solved))
+	assert.Equal(t, 1, len(rec.stable))
+	require.True(t, len(rec.newBests) > 0)
+
+	last := rec.newBests[len(rec.newBests)-1]
+	assert.Equal(t, 0.0, last.Score)
+	for i := 1; i < len(rec.newBests); i++ {
+		assert.True(t, rec.newBests[i].Score >= rec.newBests[i-1].Score)
+	}
+
+	it := rec.iterations[0]
+	assert.Equal(t, 0, it.Iteration)
+	assert.Equal(t, 200, it.Population.Forms)
+	assert.True(t, it.Population.WorstScore <= it.BestScore)
+
+	// Every line is a JSON object naming its event.
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(&jsonl)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line struct {
+			Event   string `json:"event"`
+			Program string `json:"program"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		counts[line.Event]++
+		if line.Event == "stable" {
+			assert.Contains(t, line.Program, "copyres")
+		}
+	}
+	assert.Equal(t, len(rec.iterations), counts["iteration"])
+	assert.Equal(t, len(rec.newBests), counts["new_best"])
+	assert.Equal(t, 1, counts["stable"])
+}
@@ -1,15 +1,16 @@
 package evo
 
 import (
-	"time"
 	"math/rand"
 	"math"
 	"fmt"
 )
 
+// Answer and Score are called concurrently from several goroutines and must
+// not modify shared state.
 type ProblemInterface interface {
 	Answer([]int) []int
-	GenerateInputs() []int
+	GenerateInputs(*rand.Rand) []int
 	Score([]int, []int) float64
 }
 
@@ -86,10 +87,8 @@ func (p Problem) Score(correct []int, actual []int) float64 {
 	return -gap
 }
 
-var rng *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
-
 // For most problems we can generate all random inputs.
-func (p Problem) GenerateInputs() []int {
+func (p Problem) GenerateInputs(rng *rand.Rand) []int {
 	input := make([]int, 10)
 
 	input[0] = rng.Intn(PROBLEM_INPUT_RANGE * 2) - PROBLEM_INPUT_RANGE
@@ -9,8 +9,8 @@ import (
 func TestProblem(t *testing.T) {
 	var tp AdditionProblem
 
-	input := tp.GenerateInputs()
+	input := tp.GenerateInputs(testRand())
 
 	assert.Equal(t, len(input), 10)
-	assert.Equal(t, tp.Answer(input), input[0] + input[1])
+	assert.Equal(t, tp.Answer(input), []int{input[0] + input[1]})
 }
\ No newline at end of file
@@ -0,0 +1,223 @@
+package evo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+)
+
+// A problem as written in a problem file, in JSON:
+//
+//	{
+//	  "name": "sum3",
+//	  "description": "output0 = input0 + input1 + input2",
+//	  "inputs": 3,
+//	  "min": -50, "max": 50,
+//	  "answer": ["in0 + in1 + in2"],
+//	  "scoring": "absolute",
+//	  "penalizeExtra": true
+//	}
+//
+// The answer is either an expression per output (see expr) or a table of
+// examples, in which case inputs are drawn from the examples:
+//
+//	"examples": [
+//	  {"input": [0, 0], "output": [0]},
+//	  {"input": [0, 1], "output": [1]},
+//	  {"input": [1, 0], "output": [1]},
+//	  {"input": [1, 1], "output": [0]}
+//	]
+type ProblemSpec struct {
+	Name        string
+	Description string
+
+	// Number of inputs; may be left out with examples.
+	Inputs int
+
+	// Range inputs are drawn from, inclusive.  Both 0 means
+	// +-PROBLEM_INPUT_RANGE.  Ranges overrides them per input.
+	Min, Max int
+	Ranges   [][2]int
+
+	// Expression for each output.
+	Answer []string
+
+	// Input and expected output pairs, instead of Answer.  An input may be
+	// repeated only with the same output.
+	Examples []ProblemExample
+
+	// How outputs are compared with the answer, see ScoringNames.  Empty
+	// means "absolute".
+	Scoring string
+
+	// Penalize non-zero outputs past the answer's, see
+	// PenalizeExtraOutputs.
+	PenalizeExtra bool
+}
+
+type ProblemExample struct {
+	Input  []int
+	Output []int
+}
+
+// A problem defined by a ProblemSpec rather than Go code.
+type FileProblem struct {
+	Problem
+
+	Spec ProblemSpec
+
+	// Parsed Spec.Answer.
+	answer []expr
+
+	// Spec.Examples by input.
+	examples map[string][]int
+
+	score Scorer
+}
+
+// Load a problem file.
+func ProblemFromFile(path string) (*FileProblem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	p, err := ReadProblem(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p, nil
+}
+
+// Read a problem file's JSON.
+func ReadProblem(r io.Reader) (*FileProblem, error) {
+	var spec ProblemSpec
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("reading problem: %v", err)
+	}
+	return NewFileProblem(spec)
+}
+
+// Check the spec and build its problem.
+func NewFileProblem(spec ProblemSpec) (*FileProblem, error) {
+	p := &FileProblem{Spec: spec}
+
+	if spec.Scoring == "" {
+		p.Spec.Scoring = SCOREABSOLUTE
+	}
+	score, err := NewScorer(p.Spec.Scoring)
+	if err != nil {
+		return nil, fmt.Errorf("problem: %v", err)
+	}
+	if spec.PenalizeExtra {
+		score = PenalizeExtraOutputs(score)
+	}
+	p.score = score
+
+	switch {
+	case len(spec.Answer) > 0 && len(spec.Examples) > 0:
+		return nil, fmt.Errorf("problem: give an answer or examples, not both")
+	case len(spec.Answer) == 0 && len(spec.Examples) == 0:
+		return nil, fmt.Errorf("problem: no answer or examples")
+	}
+
+	if len(spec.Examples) > 0 {
+		if spec.Inputs == 0 {
+			p.Spec.Inputs = len(spec.Examples[0].Input)
+		}
+		p.examples = map[string][]int{}
+		seen := map[string]int{}
+		for i, ex := range spec.Examples {
+			if len(ex.Input) != p.Spec.Inputs {
+				return nil, fmt.Errorf("problem: example %d has %d inputs, want %d", i, len(ex.Input), p.Spec.Inputs)
+			}
+			if len(ex.Output) == 0 || len(ex.Output) != len(spec.Examples[0].Output) {
+				return nil, fmt.Errorf("problem: example %d has %d outputs, want %d", i, len(ex.Output), len(spec.Examples[0].Output))
+			}
+			key := fmt.Sprint(ex.Input)
+			if j, ok := seen[key]; ok {
+				if fmt.Sprint(ex.Output) != fmt.Sprint(p.examples[key]) {
+					return nil, fmt.Errorf("problem: examples %d and %d have input %v but different outputs", j, i, ex.Input)
+				}
+				continue
+			}
+			seen[key] = i
+			p.examples[key] = ex.Output
+		}
+	}
+
+	if p.Spec.Inputs < 1 {
+		return nil, fmt.Errorf("problem: Inputs must be at least 1, got %d", p.Spec.Inputs)
+	}
+	if spec.Min == 0 && spec.Max == 0 {
+		p.Spec.Min, p.Spec.Max = -PROBLEM_INPUT_RANGE, PROBLEM_INPUT_RANGE
+	}
+	if p.Spec.Min > p.Spec.Max {
+		return nil, fmt.Errorf("problem: Min %d is above Max %d", p.Spec.Min, p.Spec.Max)
+	}
+	if spec.Ranges != nil {
+		if len(spec.Ranges) != p.Spec.Inputs {
+			return nil, fmt.Errorf("problem: %d ranges for %d inputs", len(spec.Ranges), p.Spec.Inputs)
+		}
+		for i, r := range spec.Ranges {
+			if r[0] > r[1] {
+				return nil, fmt.Errorf("problem: range of input %d is empty", i)
+			}
+		}
+	}
+
+	for _, s := range spec.Answer {
+		e, err := parseExpr(s, p.Spec.Inputs)
+		if err != nil {
+			return nil, fmt.Errorf("problem: %v", err)
+		}
+		p.answer = append(p.answer, e)
+	}
+
+	return p, nil
+}
+
+// Inputs within the spec's ranges, or a random example's input.
+func (p *FileProblem) GenerateInputs(rng *rand.Rand) []int {
+	if len(p.Spec.Examples) > 0 {
+		ex := p.Spec.Examples[rng.Intn(len(p.Spec.Examples))]
+		return append([]int(nil), ex.Input...)
+	}
+
+	input := make([]int, p.Spec.Inputs)
+	for i := range input {
+		lo, hi := p.Spec.Min, p.Spec.Max
+		if p.Spec.Ranges != nil {
+			lo, hi = p.Spec.Ranges[i][0], p.Spec.Ranges[i][1]
+		}
+		input[i] = lo + rng.Intn(hi-lo+1)
+	}
+	return input
+}
+
+// The answer expressions' values, or the example's output.  Inputs not in
+// the examples, which GenerateInputs never draws, answer 0 on every output
+// rather than nil, which would score as perfect.
+func (p *FileProblem) Answer(input []int) []int {
+	if p.examples != nil {
+		if answer, ok := p.examples[fmt.Sprint(input)]; ok {
+			return answer
+		}
+		return make([]int, len(p.Spec.Examples[0].Output))
+	}
+
+	answer := make([]int, len(p.answer))
+	for i, e := range p.answer {
+		answer[i] = e.eval(input)
+	}
+	return answer
+}
+
+func (p *FileProblem) Score(correct []int, actual []int) float64 {
+	return p.score(correct, actual)
+}
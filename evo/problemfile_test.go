@@ -0,0 +1,107 @@
+package evo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProblemExpression(t *testing.T) {
+	p, err := ReadProblem(strings.NewReader(`{
+		"name": "sum3",
+		"inputs": 3,
+		"min": -5, "max": 5,
+		"answer": ["in0 + in1 + in2", "in0"]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "sum3", p.Spec.Name)
+	assert.Equal(t, "absolute", p.Spec.Scoring)
+
+	rng := testRand()
+	for i := 0; i < 100; i++ {
+		input := p.GenerateInputs(rng)
+		require.Equal(t, 3, len(input))
+		for _, v := range input {
+			assert.True(t, v >= -5 && v <= 5, "%v", input)
+		}
+		assert.Equal(t, []int{input[0] + input[1] + input[2], input[0]}, p.Answer(input))
+	}
+	assert.Equal(t, -5.0, p.Score([]int{1, 2}, []int{3, 5}))
+}
+
+func TestReadProblemExamples(t *testing.T) {
+	p, err := ReadProblem(strings.NewReader(`{
+		"examples": [
+			{"input": [0, 0], "output": [0]},
+			{"input": [0, 1], "output": [1]},
+			{"input": [1, 0], "output": [1]},
+			{"input": [1, 1], "output": [0]},
+			{"input": [0, 1], "output": [1]}
+		],
+		"scoring": "exact"
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.Spec.Inputs)
+
+	seen := map[string]bool{}
+	rng := testRand()
+	for i := 0; i < 100; i++ {
+		input := p.GenerateInputs(rng)
+		seen[fmt.Sprint(input)] = true
+		assert.Equal(t, []int{input[0] ^ input[1]}, p.Answer(input))
+	}
+	assert.Equal(t, 4, len(seen))
+	assert.Equal(t, -1.0, p.Score([]int{1, 0}, []int{1, 5}))
+
+	// Other inputs answer zeros, so a program's output there isn't
+	// scored as perfect.
+	assert.Equal(t, []int{0}, p.Answer([]int{2, 3}))
+	assert.Equal(t, -1.0, p.Score(p.Answer([]int{2, 3}), []int{7}))
+}
+
+func TestReadProblemErrors(t *testing.T) {
+	for _, s := range []string{
+		`{"inputs": 2}`,
+		`{"inputs": 0, "answer": ["1"]}`,
+		`{"inputs": 2, "answer": ["in2"]}`,
+		`{"inputs": 2, "answer": ["in0"], "scoring": "vibes"}`,
+		`{"inputs": 2, "answer": ["in0"], "min": 5, "max": 1}`,
+		`{"inputs": 2, "answer": ["in0"], "ranges": [[0, 1]]}`,
+		`{"inputs": 1, "answer": ["in0"], "examples": [{"input": [1], "output": [1]}]}`,
+		`{"examples": [{"input": [1], "output": [1]}, {"input": [1, 2], "output": [1]}]}`,
+		`{"examples": [{"input": [1], "output": [1]}, {"input": [1], "output": [2]}]}`,
+		`{"inputs": 1, "answer": ["in0"], "colour": "red"}`,
+		`not json`,
+	} {
+		_, err := ReadProblem(strings.NewReader(s))
+		assert.Error(t, err, s)
+	}
+}
+
+func TestProblemFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "double.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"inputs": 1, "min": -20, "max": 20, "answer": ["in0 + in0"]}`), 0644))
+
+	p, err := ProblemFromFile(path)
+	require.NoError(t, err)
+
+	c := DefaultConfig()
+	c.Forms = 1000
+	c.StabilityDuration = 5
+	c.Seed = 1
+	e, err := NewEvolver(p, c)
+	require.NoError(t, err)
+	e.StopWhen(SolvedAndStable(), MaxIterations(300))
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, res.Solved, res.Reason)
+
+	_, err = ProblemFromFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
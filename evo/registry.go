@@ -0,0 +1,125 @@
+package evo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Difficulty tags of registered problems.
+const (
+	DIFFICULTYEASY   = "easy"
+	DIFFICULTYMEDIUM = "medium"
+	DIFFICULTYHARD   = "hard"
+)
+
+// A registered problem and what it asks for.
+type ProblemInfo struct {
+	Name        string
+	Description string
+
+	// Number of inputs the answer depends on and outputs it has.
+	Inputs  int
+	Outputs int
+
+	// One of DIFFICULTYEASY, DIFFICULTYMEDIUM or DIFFICULTYHARD.
+	Difficulty string
+
+	Problem ProblemInterface
+}
+
+// Problems by name.  Safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	problems map[string]ProblemInfo
+}
+
+func NewRegistry() *Registry {
+	return &Registry{problems: map[string]ProblemInfo{}}
+}
+
+// Add a problem.  Names are unique.
+func (r *Registry) Register(info ProblemInfo) error {
+	switch {
+	case info.Name == "":
+		return fmt.Errorf("registry: problem has no name")
+	case info.Problem == nil:
+		return fmt.Errorf("registry: problem %q is nil", info.Name)
+	case info.Inputs < 0 || info.Outputs < 1:
+		return fmt.Errorf("registry: problem %q has %d inputs and %d outputs", info.Name, info.Inputs, info.Outputs)
+	}
+	switch info.Difficulty {
+	case DIFFICULTYEASY, DIFFICULTYMEDIUM, DIFFICULTYHARD:
+	default:
+		return fmt.Errorf("registry: problem %q has unknown difficulty %q", info.Name, info.Difficulty)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.problems[info.Name]; ok {
+		return fmt.Errorf("registry: problem %q is already registered", info.Name)
+	}
+	r.problems[info.Name] = info
+	return nil
+}
+
+// The problem registered as name.
+func (r *Registry) Lookup(name string) (ProblemInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.problems[name]
+	if !ok {
+		return ProblemInfo{}, fmt.Errorf("unknown problem %q", name)
+	}
+	return info, nil
+}
+
+// The registered problems sorted by name.
+func (r *Registry) List() []ProblemInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]ProblemInfo, 0, len(r.problems))
+	for _, info := range r.problems {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// The registry Register, Lookup and List use, holding the built-in problems.
+var Problems = NewRegistry()
+
+// Add a problem to Problems.
+func Register(info ProblemInfo) error {
+	return Problems.Register(info)
+}
+
+// The problem registered in Problems as name.
+func Lookup(name string) (ProblemInfo, error) {
+	return Problems.Lookup(name)
+}
+
+// The problems in Problems sorted by name.
+func List() []ProblemInfo {
+	return Problems.List()
+}
+
+// Register problems that can't fail to register.
+func mustRegister(infos ...ProblemInfo) {
+	for _, info := range infos {
+		if err := Register(info); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func init() {
+	mustRegister(
+		ProblemInfo{"addition", "output0 = input0 + input1", 2, 1, DIFFICULTYMEDIUM, AdditionProblem{}},
+		ProblemInfo{"subtraction", "output0 = input0 - input1", 2, 1, DIFFICULTYMEDIUM, SubtractionProblem{}},
+		ProblemInfo{"multiply", "output0 = input0 * input1", 2, 1, DIFFICULTYHARD, MultiplyProblem{}},
+		ProblemInfo{"copy", "output0 = input0", 1, 1, DIFFICULTYEASY, CopyProblem{}},
+		ProblemInfo{"copy3", "output0..2 = input0..2", 3, 3, DIFFICULTYMEDIUM, Copy3Problem{}},
+		ProblemInfo{"output1", "output0 = 1", 0, 1, DIFFICULTYEASY, Output1Problem{}},
+	)
+}
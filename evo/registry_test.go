@@ -0,0 +1,60 @@
+package evo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinProblems(t *testing.T) {
+	names := map[string]bool{}
+	for _, info := range List() {
+		names[info.Name] = true
+
+		// Each problem's answer has the registered number of outputs.
+		input := info.Problem.GenerateInputs(testRand())
+		assert.True(t, len(input) >= info.Inputs, info.Name)
+		assert.Equal(t, info.Outputs, len(info.Problem.Answer(input)), info.Name)
+	}
+	for _, name := range []string{"addition", "copy", "copy3", "multiply", "output1", "subtraction"} {
+		assert.True(t, names[name], name)
+	}
+
+	info, err := Lookup("copy3")
+	require.NoError(t, err)
+	assert.Equal(t, Copy3Problem{}, info.Problem)
+	assert.Equal(t, 3, info.Inputs)
+	assert.Equal(t, DIFFICULTYMEDIUM, info.Difficulty)
+
+	_, err = Lookup("nope")
+	assert.Error(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	copy := ProblemInfo{Name: "copy", Outputs: 1, Inputs: 1, Difficulty: DIFFICULTYEASY, Problem: CopyProblem{}}
+	require.NoError(t, r.Register(copy))
+	require.NoError(t, r.Register(ProblemInfo{Name: "a", Outputs: 1, Difficulty: DIFFICULTYHARD, Problem: Output1Problem{}}))
+
+	list := r.List()
+	require.Equal(t, 2, len(list))
+	assert.Equal(t, "a", list[0].Name)
+	assert.Equal(t, copy, list[1])
+
+	got, err := r.Lookup("copy")
+	require.NoError(t, err)
+	assert.Equal(t, copy, got)
+
+	bad := []ProblemInfo{
+		copy,
+		{Outputs: 1, Difficulty: DIFFICULTYEASY, Problem: CopyProblem{}},
+		{Name: "nil", Outputs: 1, Difficulty: DIFFICULTYEASY},
+		{Name: "none", Difficulty: DIFFICULTYEASY, Problem: CopyProblem{}},
+		{Name: "tag", Outputs: 1, Difficulty: "trivial", Problem: CopyProblem{}},
+	}
+	for _, info := range bad {
+		assert.Error(t, r.Register(info), info.Name)
+	}
+	assert.Equal(t, 2, len(r.List()))
+}
@@ -0,0 +1,740 @@
+package evo
+
+// Distributed islands.
+//
+// A Worker hosts islands for a Coordinator, which runs the same island model
+// as an Archipelago with every island on a (possibly) different machine.
+// They talk net/rpc (gob encoded) over TCP or unix sockets.  Either side may
+// dial: a coordinator can Dial a listening worker, or a worker can Join a
+// listening coordinator, after which the worker serves RPCs on the
+// connection it opened.  Either way each connection hosts one island and the
+// coordinator makes these calls on it:
+//
+//	Island.Start(StartArgs, *StartReply)
+//		Create the island for the named problem from a JSON Config, or
+//		resume it from a checkpoint taken by Island.Checkpoint.
+//
+//	Island.Evolve(EvolveArgs, *EvolveReply)
+//		Take in the immigrants, then evolve for the given number of
+//		iterations and reply with the island's progress and its best forms
+//		as emigrants.
+//
+//	Island.Checkpoint(CheckpointArgs, *CheckpointReply)
+//		The island's state in the Evolver.Checkpoint format.
+//
+// Workers join by connecting at any time and start at the coordinator's next
+// migration.  A worker leaves by closing its connection (or failing); its
+// island restarts on the next worker to join, from its last checkpoint or,
+// without one, afresh from the configuration.
+// Islands use the default instruction set.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Finds the problem a coordinator names.
+type ProblemResolver func(name string) (ProblemInterface, error)
+
+type StartArgs struct {
+	// Problem name for the worker's ProblemResolver.
+	Problem string
+
+	// The island's Config as JSON.  Ignored when resuming.
+	Config []byte
+
+	// Checkpoint to resume the island from; empty to start afresh.
+	Checkpoint []byte
+}
+
+type StartReply struct {
+	// Completed iterations of the island.
+	Iteration int
+}
+
+type EvolveArgs struct {
+	Iterations int
+
+	// Number of emigrants wanted.
+	Migrants int
+
+	// Forms replacing the island's worst before it evolves.
+	Immigrants []FormRecord
+}
+
+type EvolveReply struct {
+	// Completed iterations of the island.
+	Iteration int
+
+	// Form runs during this call.
+	Evaluations int64
+
+	BestScore float64
+	BestCost  float64
+	Best      FormRecord
+
+	Solved     bool
+	Stable     bool
+	StableFor  int
+	Population PopulationStats
+
+	// The best form's validation, as Result.
+	Validated       bool
+	ValidationScore float64
+
+	// The island's best forms, fittest first.
+	Emigrants []FormRecord
+}
+
+type CheckpointArgs struct{}
+
+type CheckpointReply struct {
+	Checkpoint []byte
+}
+
+// Network and address for net.Dial or net.Listen from "unix:PATH",
+// "tcp:HOST:PORT" or "HOST:PORT".
+func ParseAddr(addr string) (string, string) {
+	if strings.HasPrefix(addr, "unix:") {
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	return "tcp", strings.TrimPrefix(addr, "tcp:")
+}
+
+// A Worker hosts islands for coordinators, one per connection.
+type Worker struct {
+	resolve ProblemResolver
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	conns     []io.Closer
+}
+
+func NewWorker(resolve ProblemResolver) *Worker {
+	return &Worker{resolve: resolve}
+}
+
+// Serve coordinators connecting to l until l is closed.
+func (w *Worker) Serve(l net.Listener) error {
+	w.mu.Lock()
+	w.listeners = append(w.listeners, l)
+	w.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go w.ServeConn(conn)
+	}
+}
+
+// Connect to the coordinator listening at addr and host an island for it
+// until it hangs up.
+func (w *Worker) Join(addr string) error {
+	network, address := ParseAddr(addr)
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return err
+	}
+	w.ServeConn(conn)
+	return nil
+}
+
+// Host an island for the coordinator on conn until it hangs up.
+func (w *Worker) ServeConn(conn io.ReadWriteCloser) {
+	w.mu.Lock()
+	w.conns = append(w.conns, conn)
+	w.mu.Unlock()
+
+	server := rpc.NewServer()
+	server.RegisterName("Island", &islandService{resolve: w.resolve})
+	server.ServeConn(conn)
+}
+
+// Stop serving and drop every coordinator connection.
+func (w *Worker) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, l := range w.listeners {
+		l.Close()
+	}
+	for _, c := range w.conns {
+		c.Close()
+	}
+	w.listeners, w.conns = nil, nil
+	return nil
+}
+
+// The RPC methods of one hosted island.
+type islandService struct {
+	resolve ProblemResolver
+
+	mu     sync.Mutex
+	island *Evolver
+
+	// Has the island been evaluated since it last bred?
+	evaluated bool
+}
+
+func (s *islandService) Start(args StartArgs, reply *StartReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.resolve(args.Problem)
+	if err != nil {
+		return err
+	}
+
+	var e Evolver
+	if len(args.Checkpoint) > 0 {
+		// Checkpoints are taken between calls to Evolve, when the island
+		// has been evaluated.
+		e, err = LoadEvolver(bytes.NewReader(args.Checkpoint), p)
+		s.evaluated = true
+	} else {
+		c := DefaultConfig()
+		if err := json.Unmarshal(args.Config, &c); err != nil {
+			return fmt.Errorf("reading config: %v", err)
+		}
+		e, err = NewEvolver(p, c)
+		s.evaluated = false
+	}
+	if err != nil {
+		return err
+	}
+
+	s.island = &e
+	reply.Iteration = e.iteration
+	return nil
+}
+
+func (s *islandService) Evolve(args EvolveArgs, reply *EvolveReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.island == nil {
+		return fmt.Errorf("island not started")
+	}
+	if args.Iterations < 1 {
+		return fmt.Errorf("Iterations must be at least 1, got %d", args.Iterations)
+	}
+	e := s.island
+	before := e.evaluations
+
+	n := args.Iterations
+	if !s.evaluated {
+		e.step()
+		s.evaluated = true
+		n--
+	}
+
+	var immigrants []Form
+	for _, r := range args.Immigrants {
+		immigrants = append(immigrants, r.form(e.cfg))
+	}
+	e.immigrate(immigrants)
+
+	for ; n > 0; n-- {
+		e.advance()
+		e.step()
+	}
+
+	best := e.forms[e.best]
+	*reply = EvolveReply{
+		Iteration:   e.iteration,
+		Evaluations: e.evaluations - before,
+		BestScore:   best.AvgScore(),
+		BestCost:    best.AvgCost(),
+		Best:        newFormRecord(best),
+		Solved:      e.solved,
+		Stable:      e.solvedNStable,
+		StableFor:   e.sameSolvedCostCount,
+		Population:  e.populationStats(),
+
+		Validated:       len(e.validation) > 0,
+		ValidationScore: e.validationScore,
+	}
+	for _, f := range e.emigrants(args.Migrants) {
+		reply.Emigrants = append(reply.Emigrants, newFormRecord(f))
+	}
+	return nil
+}
+
+func (s *islandService) Checkpoint(args CheckpointArgs, reply *CheckpointReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.island == nil {
+		return fmt.Errorf("island not started")
+	}
+	var buf bytes.Buffer
+	if err := s.island.Checkpoint(&buf); err != nil {
+		return err
+	}
+	reply.Checkpoint = buf.Bytes()
+	return nil
+}
+
+// Settings for a Coordinator.
+type CoordinatorConfig struct {
+	// Problem name sent to workers.
+	Problem string
+
+	// Configuration of every island.  Each island gets its own seed from
+	// Seed.
+	Config Config
+
+	// Iterations each island evolves between migrations.  Stop conditions
+	// are checked after each migration interval.
+	MigrationInterval int
+
+	// Best forms each island sends per migration.
+	Migrants int
+
+	// See ArchipelagoConfig.Topology.
+	Topology string
+
+	// Seed for the islands' seeds and random migration.  0 seeds from the
+	// clock.
+	Seed int64
+
+	// Migration intervals between fetching the islands' checkpoints, which
+	// are used to restart the islands of workers that leave.  0 never
+	// fetches them, so such islands restart afresh.
+	CheckpointEvery int
+}
+
+// Settings as DefaultArchipelagoConfig, checkpointing every migration.
+func DefaultCoordinatorConfig(problem string, c Config) CoordinatorConfig {
+	return CoordinatorConfig{
+		Problem:           problem,
+		Config:            c,
+		MigrationInterval: 25,
+		Migrants:          5,
+		Topology:          TOPOLOGYRING,
+		Seed:              c.Seed,
+		CheckpointEvery:   1,
+	}
+}
+
+// A worker connection seen by the coordinator.
+type remoteIsland struct {
+	name   string
+	client *rpc.Client
+
+	// Last checkpoint of the island.
+	checkpoint []byte
+
+	// Forms to send with the next Evolve call.
+	immigrants []FormRecord
+
+	// Reply to the last Evolve call.
+	last EvolveReply
+}
+
+// A Coordinator runs an island model over remote workers.
+type Coordinator struct {
+	cfg CoordinatorConfig
+
+	// Draws island seeds and random migration destinations.
+	rng *rand.Rand
+
+	// Workers that have connected but not yet been started.
+	mu      sync.Mutex
+	pending []*remoteIsland
+	joined  chan struct{}
+
+	// Islands taking part in the run.  Only changed by Run, under mu.
+	islands []*remoteIsland
+
+	// Checkpoints of islands whose worker left, waiting for a new worker.
+	// An island without a checkpoint has a nil one and starts afresh.
+	orphans [][]byte
+
+	// Number of completed iterations and migrations.
+	iteration  int
+	migrations int
+
+	evaluations int64
+
+	// Best form of the most recent migration interval.
+	best      Form
+	bestScore float64
+	bestCost  float64
+
+	// The best island's validation, as Result.
+	validated       bool
+	validationScore float64
+
+	// Best score and its cost ever seen, as Evolver.
+	topScore   float64
+	topCost    float64
+	improvedAt int
+
+	solved bool
+	stable bool
+
+	// When to stop Run; nil for SolvedAndStable.
+	stop StopCondition
+
+	observers []Observer
+}
+
+func NewCoordinator(cc CoordinatorConfig) (*Coordinator, error) {
+	if err := cc.Config.Validate(); err != nil {
+		return nil, err
+	}
+	if cc.MigrationInterval < 1 {
+		return nil, fmt.Errorf("coordinator: MigrationInterval must be at least 1, got %d", cc.MigrationInterval)
+	}
+	if cc.Migrants < 0 {
+		return nil, fmt.Errorf("coordinator: Migrants must not be negative, got %d", cc.Migrants)
+	}
+	if cc.CheckpointEvery < 0 {
+		return nil, fmt.Errorf("coordinator: CheckpointEvery must not be negative, got %d", cc.CheckpointEvery)
+	}
+	switch cc.Topology {
+	case TOPOLOGYRING, TOPOLOGYFULL, TOPOLOGYRANDOM:
+	default:
+		return nil, fmt.Errorf("coordinator: unknown topology %q", cc.Topology)
+	}
+
+	if cc.Seed == 0 {
+		cc.Seed = time.Now().UnixNano()
+	}
+	cc.Config.InstructionSet = nil
+	cc.Config.instructionSet()
+
+	return &Coordinator{
+		cfg:      cc,
+		rng:      rand.New(newSource(cc.Seed)),
+		joined:   make(chan struct{}, 1),
+		topScore: -math.MaxFloat64,
+	}, nil
+}
+
+// The coordinator's settings, with the seed filled in.
+func (c *Coordinator) Config() CoordinatorConfig {
+	return c.cfg
+}
+
+// Connect to the worker listening at addr.  It can be added during a run.
+func (c *Coordinator) Dial(addr string) error {
+	network, address := ParseAddr(addr)
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return err
+	}
+	c.AddConn(conn, addr)
+	return nil
+}
+
+// Accept workers joining through l until l is closed.
+func (c *Coordinator) Accept(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		c.AddConn(conn, conn.RemoteAddr().String())
+	}
+}
+
+// Add a worker on an open connection.  name identifies it in messages.
+func (c *Coordinator) AddConn(conn io.ReadWriteCloser, name string) {
+	c.mu.Lock()
+	c.pending = append(c.pending, &remoteIsland{name: name, client: rpc.NewClient(conn)})
+	c.mu.Unlock()
+
+	select {
+	case c.joined <- struct{}{}:
+	default:
+	}
+}
+
+// Number of workers connected, including those waiting to start.
+func (c *Coordinator) Workers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.islands) + len(c.pending)
+}
+
+// Number of iterations each island has completed under this coordinator.
+func (c *Coordinator) Iteration() int {
+	return c.iteration
+}
+
+// Number of migrations so far.
+func (c *Coordinator) Migrations() int {
+	return c.migrations
+}
+
+// Add an observer, notified after every migration interval.  Events describe
+// the best island.
+func (c *Coordinator) AddObserver(o Observer) {
+	c.observers = append(c.observers, o)
+}
+
+// Set when Run stops; several conditions stop on the first that triggers.
+func (c *Coordinator) StopWhen(conds ...StopCondition) {
+	if len(conds) == 1 {
+		c.stop = conds[0]
+	} else {
+		c.stop = AnyOf(conds...)
+	}
+}
+
+// Hang up on every worker.
+func (c *Coordinator) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, isl := range append(c.islands, c.pending...) {
+		isl.client.Close()
+	}
+	c.islands, c.pending = nil, nil
+	return nil
+}
+
+// Evolve the islands of the connected workers until a stop condition
+// triggers or ctx is done.  With no workers connected Run waits for one.
+func (c *Coordinator) Run(ctx context.Context) (Result, error) {
+	start := time.Now()
+
+	stop := c.stop
+	if stop == nil {
+		stop = SolvedAndStable()
+	}
+
+	for {
+		if err := c.admit(ctx); err != nil {
+			return c.result(c.runState(time.Since(start)), REASONCANCELLED), err
+		}
+
+		c.evolve()
+		if len(c.islands) == 0 {
+			continue
+		}
+		c.bookKeeping()
+		s := c.runState(time.Since(start))
+
+		if err := ctx.Err(); err != nil {
+			return c.result(s, REASONCANCELLED), err
+		}
+		if reason := stop.ShouldStop(s); reason != "" {
+			return c.result(s, reason), nil
+		}
+
+		if c.cfg.CheckpointEvery > 0 && (c.migrations+1)%c.cfg.CheckpointEvery == 0 {
+			c.fetchCheckpoints()
+		}
+		c.migrate()
+	}
+}
+
+// Start the islands of workers that have joined, waiting for one if there
+// are none.
+func (c *Coordinator) admit(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		pending := c.pending
+		c.pending = nil
+		c.mu.Unlock()
+
+		for _, isl := range pending {
+			args := StartArgs{Problem: c.cfg.Problem}
+			orphan := len(c.orphans) > 0
+			if orphan {
+				args.Checkpoint = c.orphans[0]
+			}
+			if len(args.Checkpoint) == 0 {
+				cfg := c.cfg.Config
+				cfg.Seed = c.rng.Int63() + 1
+				args.Config, _ = json.Marshal(cfg)
+			}
+
+			var reply StartReply
+			if err := isl.client.Call("Island.Start", args, &reply); err != nil {
+				fmt.Fprintf(os.Stderr, "Worker %s failed to start: %v\n", isl.name, err)
+				isl.client.Close()
+				continue
+			}
+			if orphan {
+				c.orphans = c.orphans[1:]
+				isl.checkpoint = args.Checkpoint
+			}
+
+			c.mu.Lock()
+			c.islands = append(c.islands, isl)
+			c.mu.Unlock()
+		}
+
+		if len(c.islands) > 0 {
+			return nil
+		}
+		select {
+		case <-c.joined:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Evolve every island for a migration interval, dropping those whose worker
+// has gone.
+func (c *Coordinator) evolve() {
+	errs := make([]error, len(c.islands))
+	var wg sync.WaitGroup
+	for k, isl := range c.islands {
+		wg.Add(1)
+		go func(k int, isl *remoteIsland) {
+			defer wg.Done()
+			args := EvolveArgs{Iterations: c.cfg.MigrationInterval, Migrants: c.cfg.Migrants, Immigrants: isl.immigrants}
+			var reply EvolveReply
+			errs[k] = isl.client.Call("Island.Evolve", args, &reply)
+			isl.last = reply
+			isl.immigrants = nil
+		}(k, isl)
+	}
+	wg.Wait()
+
+	var live []*remoteIsland
+	for k, isl := range c.islands {
+		if errs[k] == nil {
+			live = append(live, isl)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Worker %s left: %v\n", isl.name, errs[k])
+		isl.client.Close()
+		if isl.checkpoint == nil {
+			fmt.Fprintf(os.Stderr, "Worker %s's island had no checkpoint; it restarts afresh on the next worker\n", isl.name)
+		}
+		c.orphans = append(c.orphans, isl.checkpoint)
+	}
+
+	c.mu.Lock()
+	c.islands = live
+	c.mu.Unlock()
+}
+
+// Find the best island and notify observers, after every island has evolved
+// for a migration interval.
+func (c *Coordinator) bookKeeping() {
+	c.iteration += c.cfg.MigrationInterval
+	i := c.iteration - 1
+	wasSolved, wasStable := c.solved, c.stable
+	prevScore, prevCost := c.topScore, c.topCost
+
+	best := c.islands[0]
+	for _, isl := range c.islands {
+		c.evaluations += isl.last.Evaluations
+		c.solved = c.solved || isl.last.Solved
+		c.stable = c.stable || isl.last.Stable
+
+		candidate := HallOfFameEntry{Score: isl.last.BestScore, Cost: isl.last.BestCost}
+		if candidate.better(HallOfFameEntry{Score: best.last.BestScore, Cost: best.last.BestCost}) {
+			best = isl
+		}
+	}
+
+	c.best = best.last.Best.form(&c.cfg.Config)
+	c.bestScore, c.bestCost = best.last.BestScore, best.last.BestCost
+	c.validated, c.validationScore = best.last.Validated, best.last.ValidationScore
+	if c.bestScore > c.topScore || (c.bestScore == c.topScore && c.bestCost < c.topCost) {
+		c.topScore = c.bestScore
+		c.topCost = c.bestCost
+		c.improvedAt = c.iteration
+	}
+
+	for _, o := range c.observers {
+		o.OnIteration(IterationEvent{
+			Iteration:       i,
+			BestScore:       c.bestScore,
+			BestCost:        c.bestCost,
+			Solved:          c.solved,
+			StableFor:       best.last.StableFor,
+			Population:      best.last.Population,
+			Validated:       c.validated,
+			ValidationScore: c.validationScore,
+			Best:            c.best,
+		})
+		if c.improvedAt == c.iteration {
+			o.OnNewBest(NewBestEvent{Iteration: i, Score: c.topScore, Cost: c.topCost, PreviousScore: prevScore, PreviousCost: prevCost, Best: c.best})
+		}
+		if c.solved && !wasSolved {
+			o.OnSolved(SolvedEvent{Iteration: i, Cost: c.bestCost, Best: c.best})
+		}
+		if c.stable && !wasStable {
+			o.OnStable(StableEvent{Iteration: i, Cost: c.bestCost, StableFor: best.last.StableFor, Best: c.best})
+		}
+	}
+}
+
+// Keep a current checkpoint of every island.  A worker that fails here is
+// dropped by the next evolve.
+func (c *Coordinator) fetchCheckpoints() {
+	for _, isl := range c.islands {
+		var reply CheckpointReply
+		if err := isl.client.Call("Island.Checkpoint", CheckpointArgs{}, &reply); err == nil {
+			isl.checkpoint = reply.Checkpoint
+		}
+	}
+}
+
+// Queue each island's emigrants for the islands they migrate to.
+func (c *Coordinator) migrate() {
+	c.migrations++
+	if c.cfg.Migrants == 0 || len(c.islands) < 2 {
+		return
+	}
+
+	for from, isl := range c.islands {
+		for _, to := range destinations(c.cfg.Topology, from, len(c.islands), c.rng) {
+			c.islands[to].immigrants = append(c.islands[to].immigrants, isl.last.Emigrants...)
+		}
+	}
+}
+
+func (c *Coordinator) runState(elapsed time.Duration) RunState {
+	return RunState{
+		Iteration:        c.iteration,
+		Evaluations:      c.evaluations,
+		Elapsed:          elapsed,
+		BestScore:        c.bestScore,
+		BestCost:         c.bestCost,
+		TopScore:         c.topScore,
+		Solved:           c.solved,
+		Stable:           c.stable,
+		SinceImprovement: c.iteration - c.improvedAt,
+	}
+}
+
+func (c *Coordinator) result(s RunState, reason string) Result {
+	return Result{
+		Best:        c.best.Clone(),
+		Score:       s.BestScore,
+		Cost:        s.BestCost,
+		Solved:      s.Solved,
+		Stable:      s.Stable,
+		Iterations:  s.Iteration,
+		Evaluations: s.Evaluations,
+		Elapsed:     s.Elapsed,
+		Reason:      reason,
+
+		Validated:       c.validated,
+		ValidationScore: c.validationScore,
+	}
+}
@@ -0,0 +1,234 @@
+package evo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResolver(name string) (ProblemInterface, error) {
+	switch name {
+	case "output1":
+		return Output1Problem{}, nil
+	case "copy":
+		return CopyProblem{}, nil
+	}
+	return nil, fmt.Errorf("unknown problem %q", name)
+}
+
+// A worker listening on addr, closed when the test ends.
+func testWorker(t *testing.T, addr string) (*Worker, string) {
+	network, address := ParseAddr(addr)
+	l, err := net.Listen(network, address)
+	require.NoError(t, err)
+
+	w := NewWorker(testResolver)
+	go w.Serve(l)
+	t.Cleanup(func() { w.Close() })
+
+	if network == "unix" {
+		return w, addr
+	}
+	return w, l.Addr().String()
+}
+
+func testCoordinator(t *testing.T, problem string) *Coordinator {
+	c := DefaultConfig()
+	c.Forms = 100
+	c.StabilityDuration = 5
+	c.Workers = 1
+
+	cc := DefaultCoordinatorConfig(problem, c)
+	cc.MigrationInterval = 3
+	cc.Seed = 11
+
+	co, err := NewCoordinator(cc)
+	require.NoError(t, err)
+	t.Cleanup(func() { co.Close() })
+	return co
+}
+
+func TestParseAddr(t *testing.T) {
+	network, addr := ParseAddr("unix:/tmp/evo.sock")
+	assert.Equal(t, "unix", network)
+	assert.Equal(t, "/tmp/evo.sock", addr)
+
+	network, addr = ParseAddr("tcp:localhost:7000")
+	assert.Equal(t, "tcp", network)
+	assert.Equal(t, "localhost:7000", addr)
+
+	network, addr = ParseAddr("localhost:7000")
+	assert.Equal(t, "tcp", network)
+	assert.Equal(t, "localhost:7000", addr)
+}
+
+func TestCoordinatorRun(t *testing.T) {
+	co := testCoordinator(t, "output1")
+
+	_, a := testWorker(t, "127.0.0.1:0")
+	_, b := testWorker(t, "127.0.0.1:0")
+	_, u := testWorker(t, "unix:"+filepath.Join(t.TempDir(), "worker.sock"))
+	require.NoError(t, co.Dial(a))
+	require.NoError(t, co.Dial(b))
+	require.NoError(t, co.Dial(u))
+	assert.Equal(t, 3, co.Workers())
+
+	co.StopWhen(SolvedAndStable(), MaxIterations(600))
+	obs := &recordingObserver{}
+	co.AddObserver(obs)
+
+	res, err := co.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, res.Solved, res.Reason)
+	assert.Equal(t, 0.0, res.Score)
+	assert.Equal(t, 1, res.Best.Run(nil)[0])
+	assert.Equal(t, res.Iterations/3, len(obs.iterations))
+	assert.Equal(t, int64(3*100*RACETRIALS*res.Iterations), res.Evaluations)
+	assert.True(t, co.Migrations() > 0)
+}
+
+func TestCoordinatorWorkersJoinAndLeave(t *testing.T) {
+	co := testCoordinator(t, "copy")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go co.Accept(l)
+
+	// Workers join through the coordinator's listener.
+	first := NewWorker(testResolver)
+	go first.Join(l.Addr().String())
+	second := NewWorker(testResolver)
+	go second.Join(l.Addr().String())
+	t.Cleanup(func() { first.Close(); second.Close() })
+	waitForWorkers(t, co, 2)
+
+	// Part way through the first worker leaves.  Once the coordinator has
+	// noticed, a third joins and takes over the departed island from its
+	// checkpoint.
+	third := NewWorker(testResolver)
+	t.Cleanup(func() { third.Close() })
+	co.AddObserver(&churnObserver{
+		leaveAt: 6,
+		leave:   first,
+		joinAt:  12,
+		join: func() {
+			go third.Join(l.Addr().String())
+			waitForWorkers(t, co, 2)
+		},
+	})
+
+	co.StopWhen(MaxIterations(30))
+	res, err := co.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 30, res.Iterations)
+	assert.Equal(t, 2, co.Workers())
+	assert.Equal(t, 0, len(co.orphans), "the departed island should have been resumed")
+	for _, isl := range co.islands {
+		assert.True(t, isl.last.Iteration > 0)
+	}
+}
+
+// An island whose worker leaves before its first checkpoint restarts
+// afresh on the next worker rather than being lost.
+func TestCoordinatorRestartsIslandWithoutCheckpoint(t *testing.T) {
+	co := testCoordinator(t, "copy")
+	co.cfg.CheckpointEvery = 0
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go co.Accept(l)
+
+	first := NewWorker(testResolver)
+	go first.Join(l.Addr().String())
+	second := NewWorker(testResolver)
+	go second.Join(l.Addr().String())
+	third := NewWorker(testResolver)
+	t.Cleanup(func() { first.Close(); second.Close(); third.Close() })
+	waitForWorkers(t, co, 2)
+
+	co.AddObserver(&churnObserver{
+		leaveAt: 3,
+		leave:   first,
+		joinAt:  9,
+		join: func() {
+			require.Equal(t, 1, len(co.orphans))
+			assert.Nil(t, co.orphans[0])
+			go third.Join(l.Addr().String())
+			waitForWorkers(t, co, 2)
+		},
+	})
+
+	co.StopWhen(MaxIterations(18))
+	res, err := co.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 18, res.Iterations)
+	assert.Equal(t, 0, len(co.orphans))
+	require.Equal(t, 2, len(co.islands))
+	for _, isl := range co.islands {
+		assert.True(t, isl.last.Iteration > 0)
+	}
+}
+
+// Wait until n workers have connected to co.
+func waitForWorkers(t *testing.T, co *Coordinator, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for co.Workers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d workers, have %d", n, co.Workers())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Makes a worker leave and another join once the run reaches given
+// iterations.
+type churnObserver struct {
+	NopObserver
+	leaveAt int
+	leave   *Worker
+	joinAt  int
+	join    func()
+	left    bool
+	joined  bool
+}
+
+func (o *churnObserver) OnIteration(ev IterationEvent) {
+	if !o.left && ev.Iteration >= o.leaveAt {
+		o.left = true
+		o.leave.Close()
+	}
+	if !o.joined && ev.Iteration >= o.joinAt {
+		o.joined = true
+		o.join()
+	}
+}
+
+func TestCoordinatorWaitsForWorkers(t *testing.T) {
+	co := testCoordinator(t, "output1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := co.Run(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, REASONCANCELLED, res.Reason)
+}
+
+func TestWorkerRejectsUnknownProblem(t *testing.T) {
+	co := testCoordinator(t, "bogus")
+	_, a := testWorker(t, "127.0.0.1:0")
+	require.NoError(t, co.Dial(a))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := co.Run(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 0, co.Workers())
+}
@@ -0,0 +1,243 @@
+package evo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Reason given when Run's context is cancelled.
+const REASONCANCELLED = "cancelled"
+
+// Progress of a run, as seen by stop conditions after each iteration.
+type RunState struct {
+	// Completed iterations, including any before a checkpoint was resumed.
+	Iteration int
+
+	// Form runs so far (forms * trials per iteration).
+	Evaluations int64
+
+	// Time since Run was called.
+	Elapsed time.Duration
+
+	// Best form of the latest iteration.
+	BestScore float64
+	BestCost  float64
+
+	// Best score ever seen.
+	TopScore float64
+
+	Solved bool
+	Stable bool
+
+	// Iterations since TopScore (or the cost at TopScore) last improved.
+	SinceImprovement int
+}
+
+// The outcome of Run.
+type Result struct {
+	// Best form of the final iteration.
+	Best  Form
+	Score float64
+	Cost  float64
+
+	Solved bool
+	Stable bool
+
+	Iterations  int
+	Evaluations int64
+	Elapsed     time.Duration
+
+	// Why the run stopped.
+	Reason string
+
+	// Is there a validation set, and Best's mean score on it.  Score is
+	// Best's training score; a gap between the two marks an overfit program.
+	Validated       bool
+	ValidationScore float64
+
+	// Best programs of the whole run, best first; empty unless
+	// Config.HallOfFame is set.
+	HallOfFame []HallOfFameEntry
+
+	// The final population's Pareto front over score, cost and length,
+	// best score first; empty unless selection is NSGA2SELECTION.
+	ParetoFront []ParetoPoint
+}
+
+// A StopCondition decides when Run ends.
+type StopCondition interface {
+	// Reason to stop the run, or "" to keep going.
+	ShouldStop(s RunState) string
+}
+
+// A function usable as a StopCondition.
+type StopFunc func(s RunState) string
+
+func (f StopFunc) ShouldStop(s RunState) string {
+	return f(s)
+}
+
+// Stop when the best solution's cost has held for StabilityDuration
+// iterations.  This is the default condition.
+func SolvedAndStable() StopCondition {
+	return StopFunc(func(s RunState) string {
+		if s.Stable {
+			return "solved and stable"
+		}
+		return ""
+	})
+}
+
+// Stop after n iterations.
+func MaxIterations(n int) StopCondition {
+	return StopFunc(func(s RunState) string {
+		if s.Iteration >= n {
+			return fmt.Sprintf("reached %d iterations", n)
+		}
+		return ""
+	})
+}
+
+// Stop once the run has taken d.
+func WallClock(d time.Duration) StopCondition {
+	return StopFunc(func(s RunState) string {
+		if s.Elapsed >= d {
+			return fmt.Sprintf("ran for %v", d)
+		}
+		return ""
+	})
+}
+
+// Stop after n form runs.
+func MaxEvaluations(n int64) StopCondition {
+	return StopFunc(func(s RunState) string {
+		if s.Evaluations >= n {
+			return fmt.Sprintf("reached %d evaluations", n)
+		}
+		return ""
+	})
+}
+
+// Stop when the best score of an iteration reaches score (scores are <= 0.0).
+func TargetScore(score float64) StopCondition {
+	return StopFunc(func(s RunState) string {
+		if s.BestScore >= score {
+			return fmt.Sprintf("reached target score %g", score)
+		}
+		return ""
+	})
+}
+
+// Stop when the best score hasn't improved for n iterations.
+func Stagnation(n int) StopCondition {
+	return StopFunc(func(s RunState) string {
+		if s.SinceImprovement >= n {
+			return fmt.Sprintf("no improvement for %d iterations", n)
+		}
+		return ""
+	})
+}
+
+// Stop when any of the conditions says to.
+func AnyOf(conds ...StopCondition) StopCondition {
+	return StopFunc(func(s RunState) string {
+		for _, c := range conds {
+			if reason := c.ShouldStop(s); reason != "" {
+				return reason
+			}
+		}
+		return ""
+	})
+}
+
+// Stop only when all of the conditions say to.
+func AllOf(conds ...StopCondition) StopCondition {
+	return StopFunc(func(s RunState) string {
+		var reasons []string
+		for _, c := range conds {
+			reason := c.ShouldStop(s)
+			if reason == "" {
+				return ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return strings.Join(reasons, " and ")
+	})
+}
+
+// Set when Run stops; several conditions stop on the first that triggers.
+func (e *Evolver) StopWhen(conds ...StopCondition) {
+	if len(conds) == 1 {
+		e.stop = conds[0]
+	} else {
+		e.stop = AnyOf(conds...)
+	}
+}
+
+// Evolve until a stop condition triggers or ctx is done.  The iteration in
+// progress when ctx is cancelled completes; the result then has reason
+// REASONCANCELLED and the context's error is returned with it.
+func (e *Evolver) Run(ctx context.Context) (Result, error) {
+	start := time.Now()
+
+	stop := e.stop
+	if stop == nil {
+		stop = SolvedAndStable()
+	}
+
+	for {
+		e.step()
+		s := e.runState(time.Since(start))
+
+		if err := ctx.Err(); err != nil {
+			return e.result(s, REASONCANCELLED), err
+		}
+		if reason := stop.ShouldStop(s); reason != "" {
+			return e.result(s, reason), nil
+		}
+
+		e.advance()
+	}
+}
+
+func (e *Evolver) runState(elapsed time.Duration) RunState {
+	best := &e.forms[e.best]
+	return RunState{
+		Iteration:        e.iteration,
+		Evaluations:      e.evaluations,
+		Elapsed:          elapsed,
+		BestScore:        best.AvgScore(),
+		BestCost:         best.AvgCost(),
+		TopScore:         e.topScore,
+		Solved:           e.solved,
+		Stable:           e.solvedNStable,
+		SinceImprovement: e.iteration - e.improvedAt,
+	}
+}
+
+func (e *Evolver) result(s RunState, reason string) Result {
+	r := Result{
+		Best:        e.Best(),
+		Score:       s.BestScore,
+		Cost:        s.BestCost,
+		Solved:      s.Solved,
+		Stable:      s.Stable,
+		Iterations:  s.Iteration,
+		Evaluations: s.Evaluations,
+		Elapsed:     s.Elapsed,
+		Reason:      reason,
+	}
+	if len(e.validation) > 0 {
+		r.Validated = true
+		r.ValidationScore = e.validationScore
+	}
+	if e.hallOfFame != nil {
+		r.HallOfFame = e.hallOfFame.Entries()
+	}
+	if e.cfg.Selection == NSGA2SELECTION {
+		r.ParetoFront = ParetoFront(e.forms)
+	}
+	return r
+}
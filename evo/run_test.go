@@ -0,0 +1,69 @@
+package evo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMaxIterations(t *testing.T) {
+	e := testEvolver(t, MultiplyProblem{}, 50, 1, 0)
+	e.StopWhen(SolvedAndStable(), MaxIterations(4))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Iterations)
+	assert.Equal(t, "reached 4 iterations", res.Reason)
+	assert.Equal(t, int64(4*50*RACETRIALS), res.Evaluations)
+	assert.Equal(t, res.Score, res.Best.AvgScore())
+}
+
+func TestRunMaxEvaluations(t *testing.T) {
+	e := testEvolver(t, MultiplyProblem{}, 50, 1, 0)
+	e.StopWhen(MaxEvaluations(2500))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Iterations)
+}
+
+func TestRunTargetScore(t *testing.T) {
+	e := testEvolver(t, Output1Problem{}, 200, 1, 0)
+	e.StopWhen(TargetScore(0.0), MaxIterations(500))
+
+	res, err := e.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "reached target score 0", res.Reason)
+	assert.Equal(t, 0.0, res.Score)
+	assert.True(t, res.Solved)
+	assert.False(t, res.Stable)
+}
+
+func TestRunCancelled(t *testing.T) {
+	e := testEvolver(t, MultiplyProblem{}, 50, 1, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := e.Run(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, REASONCANCELLED, res.Reason)
+	assert.Equal(t, 1, res.Iterations, "the iteration in progress completes")
+}
+
+func TestStopConditions(t *testing.T) {
+	s := RunState{Iteration: 10, Elapsed: time.Minute, SinceImprovement: 3}
+
+	assert.Equal(t, "", Stagnation(4).ShouldStop(s))
+	assert.Equal(t, "no improvement for 3 iterations", Stagnation(3).ShouldStop(s))
+	assert.Equal(t, "ran for 1m0s", WallClock(time.Minute).ShouldStop(s))
+
+	assert.Equal(t, "", AllOf(MaxIterations(10), Stagnation(4)).ShouldStop(s))
+	assert.Equal(t, "reached 10 iterations and no improvement for 3 iterations",
+		AllOf(MaxIterations(10), Stagnation(3)).ShouldStop(s))
+	assert.Equal(t, "no improvement for 3 iterations",
+		AnyOf(MaxIterations(11), Stagnation(3)).ShouldStop(s))
+}
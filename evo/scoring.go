@@ -0,0 +1,146 @@
+package evo
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Scores a run's outputs against the correct answer.  As with
+// ProblemInterface.Score, 0.0 is a perfect match and worse is more negative.
+type Scorer func(correct, actual []int) float64
+
+// Names of the scoring strategies, see NewScorer.
+const (
+	SCOREABSOLUTE = "absolute"
+	SCORESQUARED  = "squared"
+	SCOREEXACT    = "exact"
+	SCOREHAMMING  = "hamming"
+	SCORERELATIVE = "relative"
+	SCORELOG      = "log"
+)
+
+var scorers = map[string]Scorer{
+	// Sum of the absolute differences; what Problem scores by.
+	SCOREABSOLUTE: Problem{}.Score,
+
+	// Sum of the squared differences, punishing large misses harder.
+	SCORESQUARED: func(correct, actual []int) float64 {
+		gap := 0.0
+		for i := 0; i < len(correct) && i < len(actual); i++ {
+			d := float64(correct[i]) - float64(actual[i])
+			gap += d * d
+		}
+		return -gap
+	},
+
+	// Number of wrong outputs, for classification style answers that are
+	// right or wrong.
+	SCOREEXACT: func(correct, actual []int) float64 {
+		wrong := 0
+		for i := range correct {
+			if i >= len(actual) || actual[i] != correct[i] {
+				wrong++
+			}
+		}
+		return -float64(wrong)
+	},
+
+	// Number of differing bits, for bitwise problems where being off by one
+	// can mean many wrong bits.
+	SCOREHAMMING: func(correct, actual []int) float64 {
+		gap := 0
+		for i := 0; i < len(correct) && i < len(actual); i++ {
+			gap += bits.OnesCount64(uint64(correct[i] ^ actual[i]))
+		}
+		return -float64(gap)
+	},
+
+	// Absolute differences relative to the answers, so large and small
+	// answers weigh alike.  Answers of 0 count as 1.
+	SCORERELATIVE: func(correct, actual []int) float64 {
+		gap := 0.0
+		for i := 0; i < len(correct) && i < len(actual); i++ {
+			gap += math.Abs(float64(correct[i])-float64(actual[i])) / math.Max(math.Abs(float64(correct[i])), 1)
+		}
+		return -gap
+	},
+
+	// Logarithm of the absolute differences, so one huge miss doesn't drown
+	// out the rest.
+	SCORELOG: func(correct, actual []int) float64 {
+		gap := 0.0
+		for i := 0; i < len(correct) && i < len(actual); i++ {
+			gap += math.Log1p(math.Abs(float64(correct[i]) - float64(actual[i])))
+		}
+		return -gap
+	},
+}
+
+// Names accepted by NewScorer.
+func ScoringNames() []string {
+	var names []string
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The scoring strategy called name.  Most compare only the outputs the
+// answer has; see PenalizeExtraOutputs for the rest.
+func NewScorer(name string) (Scorer, error) {
+	s, ok := scorers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring %q", name)
+	}
+	return s, nil
+}
+
+// Score with s, less 1 for each output past the answer's that isn't 0 and
+// each output of the answer that's missing, so programs don't get away with
+// writing junk to outputs nobody checks.
+func PenalizeExtraOutputs(s Scorer) Scorer {
+	return func(correct, actual []int) float64 {
+		score := s(correct, actual)
+		for i := len(correct); i < len(actual); i++ {
+			if actual[i] != 0 {
+				score--
+			}
+		}
+		if len(actual) < len(correct) {
+			score -= float64(len(correct) - len(actual))
+		}
+		return score
+	}
+}
+
+// A problem scored by Scorer rather than its own Score.
+type ScoredProblem struct {
+	ProblemInterface
+	Scorer Scorer
+}
+
+// p scored by the strategy called scoring, penalizing extra outputs if
+// penalizeExtra.  An empty name keeps p's own scoring.
+func WithScoring(p ProblemInterface, scoring string, penalizeExtra bool) (ProblemInterface, error) {
+	if scoring == "" && !penalizeExtra {
+		return p, nil
+	}
+	var s Scorer = p.Score
+	if scoring != "" {
+		var err error
+		if s, err = NewScorer(scoring); err != nil {
+			return nil, err
+		}
+	}
+	if penalizeExtra {
+		s = PenalizeExtraOutputs(s)
+	}
+	return ScoredProblem{p, s}, nil
+}
+
+func (p ScoredProblem) Score(correct []int, actual []int) float64 {
+	return p.Scorer(correct, actual)
+}
@@ -0,0 +1,62 @@
+package evo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScorers(t *testing.T) {
+	correct := []int{10, 0, 5}
+	actual := []int{7, 2, 5, 9}
+	tests := []struct {
+		name  string
+		score float64
+	}{
+		{SCOREABSOLUTE, -5},
+		{SCORESQUARED, -13},
+		{SCOREEXACT, -2},
+		{SCOREHAMMING, -4}, // 1010^0111 has 3 bits, 0^10 has 1.
+		{SCORERELATIVE, -2.3},
+		{SCORELOG, -math.Log(4) - math.Log(3)},
+	}
+	for _, tt := range tests {
+		s, err := NewScorer(tt.name)
+		require.NoError(t, err)
+		assert.InDelta(t, tt.score, s(correct, actual), 1e-9, tt.name)
+		assert.Equal(t, 0.0, s(correct, []int{10, 0, 5, 9}), tt.name)
+	}
+	assert.Equal(t, len(tests), len(ScoringNames()))
+
+	_, err := NewScorer("vibes")
+	assert.Error(t, err)
+}
+
+func TestPenalizeExtraOutputs(t *testing.T) {
+	s := PenalizeExtraOutputs(Problem{}.Score)
+	assert.Equal(t, 0.0, s([]int{1}, []int{1, 0, 0}))
+	assert.Equal(t, -2.0, s([]int{1}, []int{1, 5, -5}))
+	assert.Equal(t, -2.0, s([]int{1}, []int{2, 0, 7}))
+	assert.Equal(t, -2.0, s([]int{1, 2, 3}, []int{1}))
+}
+
+func TestWithScoring(t *testing.T) {
+	p, err := WithScoring(AdditionProblem{}, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, AdditionProblem{}, p)
+
+	p, err = WithScoring(AdditionProblem{}, SCORESQUARED, true)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, p.Answer([]int{1, 2}))
+	assert.Equal(t, -10.0, p.Score([]int{3}, []int{0, 1}))
+
+	// Without a name the problem keeps its own scoring.
+	p, err = WithScoring(AdditionProblem{}, "", true)
+	require.NoError(t, err)
+	assert.Equal(t, -4.0, p.Score([]int{3}, []int{0, 1}))
+
+	_, err = WithScoring(AdditionProblem{}, "vibes", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package evo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// The view of an evaluated generation a Selection chooses parents from.
+// ByAvgScore is the standard Population.
+type Population interface {
+	Len() int
+
+	// Is form i fitter than form j?
+	Less(i, j int) bool
+
+	// Fitness of form i; higher is fitter.  May be negative.
+	Fitness(i int) float64
+}
+
+// A Selection picks the parents of the next generation.
+type Selection interface {
+	// Indexes of n parents chosen from pop, one per slot of the next
+	// generation.  Indexes may repeat.
+	Select(pop Population, n int, rng *rand.Rand) []int
+}
+
+// A Selection that also carries some forms into the next generation
+// unchanged, filling the first slots.  The rest are bred from parents.
+type SurvivorSelection interface {
+	Selection
+
+	// Indexes of the forms that survive.
+	Survivors(pop Population) []int
+}
+
+// A Selection with setup, such as sorting the population, that depends only
+// on the population.  The evolver prepares it once per generation and draws
+// survivors and parents from the result.
+type preparedSelection interface {
+	Selection
+
+	// A Selection for pop alone, a SurvivorSelection if this one is.
+	prepare(pop Population) Selection
+}
+
+// Name of the original selection scheme: the population is split into
+// buckets and each bucket's best form replaces the rest of its bucket with
+// mutants.  It isn't a Selection as it works in place and always keeps each
+// bucket's best form.
+const BUCKETSELECTION = "bucket"
+
+// Names accepted by Config.Selection.
+func SelectionNames() []string {
+	return []string{BUCKETSELECTION, "epsilon-lexicase", "lexicase", NSGA2SELECTION, "rank", "roulette", "tournament", "truncation"}
+}
+
+// The Selection named by c.Selection, configured from c.  Returns nil for
+// BUCKETSELECTION.
+func NewSelection(c *Config) (Selection, error) {
+	switch c.Selection {
+	case BUCKETSELECTION:
+		return nil, nil
+	case "tournament":
+		return TournamentSelection{Size: c.TournamentSize}, nil
+	case "roulette":
+		return RouletteSelection{}, nil
+	case "rank":
+		return RankSelection{Pressure: c.RankPressure}, nil
+	case "truncation":
+		return TruncationSelection{Percent: c.TruncationPercent}, nil
+	case NSGA2SELECTION:
+		return NSGA2Selection{}, nil
+	case "lexicase":
+		return LexicaseSelection{}, nil
+	case "epsilon-lexicase":
+		return LexicaseSelection{Epsilon: true}, nil
+	}
+	return nil, fmt.Errorf("unknown selection %q", c.Selection)
+}
+
+// Each parent is the fittest of Size forms drawn at random.
+type TournamentSelection struct {
+	Size int
+}
+
+func (s TournamentSelection) Select(pop Population, n int, rng *rand.Rand) []int {
+	parents := make([]int, n)
+	for slot := range parents {
+		best := rng.Intn(pop.Len())
+		for k := 1; k < s.Size; k++ {
+			if i := rng.Intn(pop.Len()); pop.Less(i, best) {
+				best = i
+			}
+		}
+		parents[slot] = best
+	}
+	return parents
+}
+
+// Fitness proportionate (roulette wheel) selection.  Fitness is shifted so
+// the least fit form has a small but non-zero chance.
+type RouletteSelection struct{}
+
+func (s RouletteSelection) Select(pop Population, n int, rng *rand.Rand) []int {
+	min, max := pop.Fitness(0), pop.Fitness(0)
+	for i := 1; i < pop.Len(); i++ {
+		min = minFloat(min, pop.Fitness(i))
+		max = maxFloat(max, pop.Fitness(i))
+	}
+
+	// Floor each weight at a sliver of the range so no form is excluded.
+	floor := (max - min) / float64(pop.Len())
+	if floor == 0 {
+		floor = 1
+	}
+
+	weights := make([]float64, pop.Len())
+	for i := range weights {
+		weights[i] = pop.Fitness(i) - min + floor
+	}
+	return spin(weights, n, rng)
+}
+
+// Linear rank selection.  The fittest form is Pressure (1 to 2) times as
+// likely to be picked as average; the least fit 2-Pressure times.
+type RankSelection struct {
+	Pressure float64
+}
+
+func (s RankSelection) Select(pop Population, n int, rng *rand.Rand) []int {
+	order := rankOrder(pop)
+	size := float64(len(order))
+
+	weights := make([]float64, len(order))
+	for rank, i := range order {
+		if len(order) == 1 {
+			weights[i] = 1
+			break
+		}
+		weights[i] = (2 - s.Pressure) + 2*(s.Pressure-1)*(size-1-float64(rank))/(size-1)
+	}
+	return spin(weights, n, rng)
+}
+
+// The top Percent of forms each parent an equal share of the next
+// generation.
+type TruncationSelection struct {
+	Percent float64
+}
+
+func (s TruncationSelection) Select(pop Population, n int, rng *rand.Rand) []int {
+	order := rankOrder(pop)
+
+	top := int(float64(len(order)) * s.Percent / 100.0)
+	if top < 1 {
+		top = 1
+	}
+
+	parents := make([]int, n)
+	for slot := range parents {
+		parents[slot] = order[slot*top/n]
+	}
+	return parents
+}
+
+// Indexes of the population, fittest first.
+func rankOrder(pop Population) []int {
+	order := make([]int, pop.Len())
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return pop.Less(order[a], order[b])
+	})
+	return order
+}
+
+// Draw n indexes with probability proportional to weights.
+func spin(weights []float64, n int, rng *rand.Rand) []int {
+	cumulative := make([]float64, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+	}
+
+	parents := make([]int, n)
+	for slot := range parents {
+		i := sort.SearchFloat64s(cumulative, rng.Float64()*total)
+		if i >= len(cumulative) {
+			i = len(cumulative) - 1
+		}
+		parents[slot] = i
+	}
+	return parents
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+package evo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Population where form i has fitness[i].
+type fitnessPop []float64
+
+func (p fitnessPop) Len() int              { return len(p) }
+func (p fitnessPop) Less(i, j int) bool    { return p[i] > p[j] }
+func (p fitnessPop) Fitness(i int) float64 { return p[i] }
+
+// Times each form was picked from 10 forms with fitness -9 (form 0) up to 0
+// (form 9).
+func selectionCounts(t *testing.T, sel Selection) []int {
+	pop := fitnessPop{-9, -8, -7, -6, -5, -4, -3, -2, -1, 0}
+	parents := sel.Select(pop, 10000, testRand())
+	require.Equal(t, 10000, len(parents))
+
+	counts := make([]int, len(pop))
+	for _, p := range parents {
+		require.True(t, p >= 0 && p < len(pop), "index %d out of range", p)
+		counts[p]++
+	}
+	return counts
+}
+
+func TestTournamentSelection(t *testing.T) {
+	counts := selectionCounts(t, TournamentSelection{Size: 3})
+	assert.True(t, counts[9] > counts[5], "%v", counts)
+	assert.True(t, counts[5] > counts[0], "%v", counts)
+
+	// A tournament of one is a uniform draw.
+	counts = selectionCounts(t, TournamentSelection{Size: 1})
+	for _, c := range counts {
+		assert.InDelta(t, 1000, float64(c), 150, "%v", counts)
+	}
+}
+
+func TestRouletteSelection(t *testing.T) {
+	counts := selectionCounts(t, RouletteSelection{})
+	assert.True(t, counts[9] > counts[5], "%v", counts)
+	assert.True(t, counts[5] > counts[0], "%v", counts)
+	assert.True(t, counts[0] > 0, "least fit form should have a chance: %v", counts)
+
+	// Equal fitness is a uniform draw.
+	parents := RouletteSelection{}.Select(fitnessPop{-2, -2, -2, -2}, 4000, testRand())
+	counts = make([]int, 4)
+	for _, p := range parents {
+		counts[p]++
+	}
+	for _, c := range counts {
+		assert.InDelta(t, 1000, float64(c), 150, "%v", counts)
+	}
+}
+
+func TestRankSelection(t *testing.T) {
+	counts := selectionCounts(t, RankSelection{Pressure: 2})
+	assert.True(t, counts[9] > counts[5], "%v", counts)
+	assert.True(t, counts[5] > counts[0], "%v", counts)
+	assert.InDelta(t, 2000, float64(counts[9]), 250, "%v", counts)
+	assert.Equal(t, 0, counts[0], "%v", counts)
+
+	counts = selectionCounts(t, RankSelection{Pressure: 1})
+	for _, c := range counts {
+		assert.InDelta(t, 1000, float64(c), 150, "%v", counts)
+	}
+
+	assert.Equal(t, []int{0, 0}, RankSelection{Pressure: 1.5}.Select(fitnessPop{0}, 2, testRand()))
+}
+
+func TestTruncationSelection(t *testing.T) {
+	counts := selectionCounts(t, TruncationSelection{Percent: 20})
+	assert.Equal(t, []int{0, 0, 0, 0, 0, 0, 0, 0, 5000, 5000}, counts)
+
+	// At least one form always breeds.
+	counts = selectionCounts(t, TruncationSelection{Percent: 1})
+	assert.Equal(t, 10000, counts[9])
+}
+
+func TestSelectionConfig(t *testing.T) {
+	for _, name := range SelectionNames() {
+		c := DefaultConfig()
+		c.Selection = name
+		require.NoError(t, c.Validate(), name)
+		sel, err := NewSelection(&c)
+		require.NoError(t, err)
+		assert.Equal(t, name == BUCKETSELECTION, sel == nil, name)
+	}
+
+	bad := []func(c *Config){
+		func(c *Config) { c.Selection = "bogus" },
+		func(c *Config) { c.Selection = "tournament"; c.TournamentSize = 0 },
+		func(c *Config) { c.Selection = "rank"; c.RankPressure = 2.5 },
+		func(c *Config) { c.Selection = "truncation"; c.TruncationPercent = 0 },
+	}
+	for i, change := range bad {
+		c := DefaultConfig()
+		change(&c)
+		assert.Error(t, c.Validate(), "case %d", i)
+	}
+}
+
+func TestEvolverSelections(t *testing.T) {
+	for _, name := range SelectionNames() {
+		c := DefaultConfig()
+		c.Forms = 200
+		c.StabilityDuration = 5
+		c.Seed = 3
+		c.Workers = 1
+		c.Selection = name
+
+		e, err := NewEvolver(Output1Problem{}, c)
+		require.NoError(t, err)
+		e.StopWhen(SolvedAndStable(), MaxIterations(500))
+
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		assert.True(t, res.Solved, "%s: %s", name, res.Reason)
+		assert.Equal(t, 200, len(e.forms), name)
+	}
+}